@@ -8,9 +8,10 @@ import (
 
 // 软件信息
 type SoftwareInfo struct {
-	*AppConfig     `mapstructure:"app"`     // 软件信息
-	*DatasetConfig `mapstructure:"dataset"` // 数据集配置
-	*MySQLConfig   `mapstructure:"mysql"`   // MySQL配置
+	*AppConfig      `mapstructure:"app"`      // 软件信息
+	*DatasetConfig  `mapstructure:"dataset"`  // 数据集配置
+	*MySQLConfig    `mapstructure:"mysql"`    // MySQL配置
+	*DatabaseConfig `mapstructure:"database"` // 存储方式配置
 }
 
 type AppConfig struct {
@@ -22,10 +23,33 @@ type AppConfig struct {
 }
 
 type DatasetConfig struct {
-	TmpDir        string `mapstructure:"tmp_dir"`
-	SaveDir       string `mapstructure:"save_dir"`
-	AutoRename    bool   `mapstructure:"auto_rename"`
-	AutoRenameKey string `mapstructure:"auto_rename_key"`
+	TmpDir             string `mapstructure:"tmp_dir"`
+	SaveDir            string `mapstructure:"save_dir"`
+	AutoRename         bool   `mapstructure:"auto_rename"`
+	AutoRenameKey      string `mapstructure:"auto_rename_key"`
+	DownloadMaxSize    int64  `mapstructure:"download_max_size"`    // 链接下载单张图片大小上限（字节），0 使用默认值
+	DownloadRetries    int    `mapstructure:"download_retries"`     // 链接下载失败重试次数，0 使用默认值
+	ArchiveMaxSize     int64  `mapstructure:"archive_max_size"`     // 压缩包解压后总大小上限（字节），0 使用默认值
+	PHashAlgorithm     string `mapstructure:"phash_algorithm"`      // 近似查重使用的感知哈希算法 ahash / dhash / phash，默认 phash
+	PHashThreshold     int    `mapstructure:"phash_threshold"`      // 近似查重的汉明距离阈值，0 使用默认值
+	ThumbnailCacheSize int64  `mapstructure:"thumbnail_cache_size"` // 缩略图缓存容量上限（字节），0 使用默认值
+	UploadWorkers      int    `mapstructure:"upload_workers"`       // 同时导入的文件数，0 使用默认值
+	UploadDatasetID    int    `mapstructure:"upload_dataset_id"`    // 上传页面上次选择的目标数据集，0 表示默认数据集
+	MinFreeSpace       int64  `mapstructure:"min_free_space"`       // 存放目录和缓存目录所在磁盘至少保留的剩余空间（字节），0 表示不检查
+
+	Validation *ValidationConfig `mapstructure:"validation"` // 入库校验规则，未配置时只检查图片能否完整解码
+}
+
+// ValidationConfig 图片入库校验规则，数值为 0 表示不限制
+type ValidationConfig struct {
+	Formats        []string `mapstructure:"formats"`          // 允许的图片格式，如 jpeg、png，为空时不限制
+	MinWidth       int      `mapstructure:"min_width"`        // 最小宽度（像素）
+	MinHeight      int      `mapstructure:"min_height"`       // 最小高度（像素）
+	MaxWidth       int      `mapstructure:"max_width"`        // 最大宽度（像素）
+	MaxHeight      int      `mapstructure:"max_height"`       // 最大高度（像素）
+	MaxFileSize    int64    `mapstructure:"max_file_size"`    // 单个文件大小上限（字节）
+	MinAspectRatio float64  `mapstructure:"min_aspect_ratio"` // 最小宽高比（宽/高）
+	MaxAspectRatio float64  `mapstructure:"max_aspect_ratio"` // 最大宽高比（宽/高）
 }
 
 type MySQLConfig struct {
@@ -38,6 +62,12 @@ type MySQLConfig struct {
 	MaxIdleConns int    `mapstructure:"max_idle_conns"`
 }
 
+// DatabaseConfig 选择数据库后端：mysql 或 sqlite（本地嵌入式，无需外部服务）
+type DatabaseConfig struct {
+	Driver     string `mapstructure:"driver"`      // 数据库类型 mysql / sqlite，默认 mysql
+	SQLitePath string `mapstructure:"sqlite_path"` // SQLite 数据库文件路径
+}
+
 var Conf = new(SoftwareInfo)
 
 func Init(confpath string) (err error) {
@@ -80,6 +110,25 @@ func SaveConfig() error {
 		viper.Set("dataset.save_dir", Conf.DatasetConfig.SaveDir)
 		viper.Set("dataset.auto_rename", Conf.DatasetConfig.AutoRename)
 		viper.Set("dataset.auto_rename_key", Conf.DatasetConfig.AutoRenameKey)
+		viper.Set("dataset.download_max_size", Conf.DatasetConfig.DownloadMaxSize)
+		viper.Set("dataset.download_retries", Conf.DatasetConfig.DownloadRetries)
+		viper.Set("dataset.archive_max_size", Conf.DatasetConfig.ArchiveMaxSize)
+		viper.Set("dataset.phash_algorithm", Conf.DatasetConfig.PHashAlgorithm)
+		viper.Set("dataset.phash_threshold", Conf.DatasetConfig.PHashThreshold)
+		viper.Set("dataset.thumbnail_cache_size", Conf.DatasetConfig.ThumbnailCacheSize)
+		viper.Set("dataset.upload_workers", Conf.DatasetConfig.UploadWorkers)
+		viper.Set("dataset.upload_dataset_id", Conf.DatasetConfig.UploadDatasetID)
+		viper.Set("dataset.min_free_space", Conf.DatasetConfig.MinFreeSpace)
+		if rules := Conf.DatasetConfig.Validation; rules != nil {
+			viper.Set("dataset.validation.formats", rules.Formats)
+			viper.Set("dataset.validation.min_width", rules.MinWidth)
+			viper.Set("dataset.validation.min_height", rules.MinHeight)
+			viper.Set("dataset.validation.max_width", rules.MaxWidth)
+			viper.Set("dataset.validation.max_height", rules.MaxHeight)
+			viper.Set("dataset.validation.max_file_size", rules.MaxFileSize)
+			viper.Set("dataset.validation.min_aspect_ratio", rules.MinAspectRatio)
+			viper.Set("dataset.validation.max_aspect_ratio", rules.MaxAspectRatio)
+		}
 	}
 
 	// Conf.MySQLConfig
@@ -93,6 +142,12 @@ func SaveConfig() error {
 		viper.Set("mysql.max_idle_conns", Conf.MySQLConfig.MaxIdleConns)
 	}
 
+	// Conf.DatabaseConfig
+	if Conf.DatabaseConfig != nil {
+		viper.Set("database.driver", Conf.DatabaseConfig.Driver)
+		viper.Set("database.sqlite_path", Conf.DatabaseConfig.SQLitePath)
+	}
+
 	// 写入配置文件
 	return viper.WriteConfig()
 }
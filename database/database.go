@@ -0,0 +1,68 @@
+package database
+
+import (
+	"database/sql"
+	"dataset-sync/conf"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// 支持的数据库类型，对应配置项 database.driver
+const (
+	DriverMySQL  = "mysql"
+	DriverSQLite = "sqlite"
+)
+
+// SQLRepository 基于 database/sql 的 DatasetRepository 实现，MySQL 与 SQLite 共用
+type SQLRepository struct {
+	db     *sql.DB // 数据库连接池
+	driver string  // 数据库类型
+}
+
+// Open 根据配置选择数据库后端并建立连接，数据表由 Migrate 创建
+func Open(dbCfg *conf.DatabaseConfig, mysqlCfg *conf.MySQLConfig) (*SQLRepository, error) {
+	driver := DriverMySQL // 未配置时沿用 MySQL
+	if dbCfg != nil && dbCfg.Driver != "" {
+		driver = strings.ToLower(dbCfg.Driver)
+	}
+
+	var (
+		conn *sql.DB
+		err  error
+	)
+	switch driver {
+	case DriverMySQL:
+		conn, err = openMySQL(mysqlCfg)
+	case DriverSQLite:
+		path := ""
+		if dbCfg != nil {
+			path = dbCfg.SQLitePath
+		}
+		conn, err = openSQLite(path)
+	default:
+		return nil, fmt.Errorf("不支持的数据库类型: %s", driver)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &SQLRepository{db: conn, driver: driver}, nil
+}
+
+// isDuplicateKey 错误是否为唯一索引冲突（MySQL 1062、SQLite UNIQUE 约束）
+// SQLite 按错误信息判断，sqlite3.Error 类型只在启用 cgo 时存在
+func isDuplicateKey(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		return mysqlErr.Number == 1062
+	}
+	return err != nil && strings.Contains(err.Error(), "UNIQUE constraint failed")
+}
+
+// Close 关闭数据库连接池
+func (r *SQLRepository) Close() error {
+	return r.db.Close()
+}
@@ -1,30 +1,207 @@
 package database
 
 import (
+	"database/sql"
 	"dataset-sync/models"
+	"errors"
 	"fmt"
-	"math/rand"
 	"time"
 )
 
-func GetDatasets() []*models.Dataset {
-	// 从数据库中获取数据集列表
-	// 返回一个包含所有数据集的切片
-	// 模拟数据 -- 数据集切片GetDatasets
+// 图片数量和总大小由 images 表实时统计，重复数量由上传记录统计
+const datasetColumns = `id, name, description,
+	(SELECT COUNT(*) FROM images WHERE images.dataset_id = datasets.id) AS image_count,
+	(SELECT COALESCE(SUM(size), 0) FROM images WHERE images.dataset_id = datasets.id) AS total_size,
+	(SELECT COUNT(*) FROM upload_history WHERE upload_history.dataset_id = datasets.id AND upload_history.duplicate = 1) AS duplicate_count,
+	created_at, updated_at, status, cover, revision, normalize_format, normalize_quality, max_images, max_size, storage_backend, storage_config`
+
+// scanDataset 将一行查询结果读取为数据集
+func scanDataset(row interface{ Scan(...any) error }) (*models.Dataset, error) {
+	ds := new(models.Dataset)
+	err := row.Scan(&ds.ID, &ds.Name, &ds.Description, &ds.ImageCount, &ds.TotalSize, &ds.DuplicateCount,
+		&ds.CreatedAt, &ds.UpdatedAt, &ds.Status, &ds.Cover, &ds.Revision, &ds.NormalizeFormat, &ds.NormalizeQuality, &ds.MaxImages, &ds.MaxSize,
+		&ds.StorageBackend, &ds.StorageConfig)
+	return ds, err
+}
+
+// ListDatasets 从数据库中获取数据集列表
+func (r *SQLRepository) ListDatasets() ([]*models.Dataset, error) {
+	rows, err := r.db.Query(`SELECT ` + datasetColumns + ` FROM datasets ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("查询数据集失败: %w", err)
+	}
+	defer rows.Close()
+
 	var datasets []*models.Dataset
-	for i := range 20 {
-		datasets = append(datasets, &models.Dataset{
-			ID:          i,
-			Name:        fmt.Sprintf("数据集%d", i),
-			Description: fmt.Sprintf("描述%d", i),
-			// 随机数量 -- 1000 + 随机数 使用round
-			ImageCount: rand.Intn(i*1000 + 100),
-			CreatedAt:  time.Now(),
-			// 随机更新时间 -- 当前时间 + 随机时间
-			UpdatedAt: time.Now().Add(time.Duration(i*20) * time.Hour),
-			Status:    1,
-			Cover:     "ui\\luna.jpg",
-		})
-	}
-	return datasets
+	for rows.Next() {
+		ds, err := scanDataset(rows)
+		if err != nil {
+			return nil, fmt.Errorf("读取数据集失败: %w", err)
+		}
+		datasets = append(datasets, ds)
+	}
+	return datasets, rows.Err()
+}
+
+// GetDataset 按 ID 获取数据集
+func (r *SQLRepository) GetDataset(id int) (*models.Dataset, error) {
+	ds, err := scanDataset(r.db.QueryRow(`SELECT `+datasetColumns+` FROM datasets WHERE id = ?`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("查询数据集失败: %w", err)
+	}
+	return ds, nil
+}
+
+// CreateDataset 新增数据集，成功后回填 ID 和时间
+func (r *SQLRepository) CreateDataset(ds *models.Dataset) error {
+	var count int
+	if err := r.db.QueryRow(`SELECT COUNT(*) FROM datasets WHERE name = ?`, ds.Name).Scan(&count); err != nil {
+		return fmt.Errorf("查询数据集失败: %w", err)
+	}
+	if count > 0 {
+		return ErrDatasetExists
+	}
+
+	now := time.Now()
+	res, err := r.db.Exec(`INSERT INTO datasets (name, description, created_at, updated_at, status, cover, normalize_format, normalize_quality, max_images, max_size,
+		storage_backend, storage_config)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		ds.Name, ds.Description, now, now, ds.Status, ds.Cover, ds.NormalizeFormat, ds.NormalizeQuality, ds.MaxImages, ds.MaxSize,
+		ds.StorageBackend, ds.StorageConfig)
+	if isDuplicateKey(err) {
+		return ErrDatasetExists // 与其他连接并发创建同名数据集
+	}
+	if err != nil {
+		return fmt.Errorf("新增数据集失败: %w", err)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("获取数据集 ID 失败: %w", err)
+	}
+	ds.ID = int(id)
+	ds.ImageCount = 0
+	ds.TotalSize = 0
+	ds.DuplicateCount = 0
+	ds.CreatedAt = now
+	ds.UpdatedAt = now
+	return nil
+}
+
+// UpdateDataset 更新数据集信息，改名为已有的名称时返回 ErrDatasetExists
+func (r *SQLRepository) UpdateDataset(ds *models.Dataset) error {
+	now := time.Now()
+	res, err := r.db.Exec(`UPDATE datasets SET name = ?, description = ?, updated_at = ?, status = ?, cover = ?,
+		normalize_format = ?, normalize_quality = ?, max_images = ?, max_size = ?,
+		storage_backend = ?, storage_config = ?
+		WHERE id = ?`,
+		ds.Name, ds.Description, now, ds.Status, ds.Cover, ds.NormalizeFormat, ds.NormalizeQuality, ds.MaxImages, ds.MaxSize,
+		ds.StorageBackend, ds.StorageConfig, ds.ID)
+	if isDuplicateKey(err) {
+		return ErrDatasetExists
+	}
+	if err != nil {
+		return fmt.Errorf("更新数据集失败: %w", err)
+	}
+	if err := checkAffected(res); err != nil {
+		return err
+	}
+	ds.UpdatedAt = now
+	return nil
+}
+
+// UpdateDatasetSettings 只更新数据集设置，不覆盖导入、同步期间修改的同步状态和封面
+// status 放在最前面：MySQL 中后面的赋值会看到前面已更新的列
+func (r *SQLRepository) UpdateDatasetSettings(ds *models.Dataset) error {
+	now := time.Now()
+	res, err := r.db.Exec(`UPDATE datasets SET
+		status = CASE WHEN storage_backend = ? AND storage_config = ? THEN status ELSE 0 END,
+		revision = CASE WHEN storage_backend = ? AND storage_config = ? THEN revision ELSE revision + 1 END,
+		updated_at = ?, normalize_format = ?, normalize_quality = ?, max_images = ?, max_size = ?,
+		storage_backend = ?, storage_config = ?
+		WHERE id = ?`,
+		ds.StorageBackend, ds.StorageConfig, ds.StorageBackend, ds.StorageConfig,
+		now, ds.NormalizeFormat, ds.NormalizeQuality, ds.MaxImages, ds.MaxSize,
+		ds.StorageBackend, ds.StorageConfig, ds.ID)
+	if err != nil {
+		return fmt.Errorf("更新数据集设置失败: %w", err)
+	}
+	if err := checkAffected(res); err != nil {
+		return err
+	}
+	ds.UpdatedAt = now
+	return nil
+}
+
+// MarkDatasetChanged 只更新同步状态、空封面和更新时间，导入期间在设置窗口中做的修改不会被覆盖
+func (r *SQLRepository) MarkDatasetChanged(id int, cover string) error {
+	res, err := r.db.Exec(`UPDATE datasets SET status = 0, revision = revision + 1, updated_at = ?,
+		cover = CASE WHEN cover = '' THEN ? ELSE cover END
+		WHERE id = ?`, time.Now(), cover, id)
+	if err != nil {
+		return fmt.Errorf("更新数据集状态失败: %w", err)
+	}
+	return checkAffected(res)
+}
+
+// MarkDatasetSynced 以 revision 作为条件标记为已同步，同步期间有新的变化时不更新
+func (r *SQLRepository) MarkDatasetSynced(id int, revision int64) (bool, error) {
+	res, err := r.db.Exec(`UPDATE datasets SET status = 1, updated_at = ? WHERE id = ? AND revision = ?`, time.Now(), id, revision)
+	if err != nil {
+		return false, fmt.Errorf("更新数据集状态失败: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("获取受影响行数失败: %w", err)
+	}
+	return n > 0, nil
+}
+
+// DeleteDataset 删除数据集
+func (r *SQLRepository) DeleteDataset(id int) error {
+	res, err := r.db.Exec(`DELETE FROM datasets WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("删除数据集失败: %w", err)
+	}
+	return checkAffected(res)
+}
+
+// NextSequence 在事务中将数据集的图片序号加一并返回新序号，并发导入时也不会重复
+func (r *SQLRepository) NextSequence(datasetID int) (int64, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("开启事务失败: %w", err)
+	}
+	defer tx.Rollback()
+
+	// 先更新再读取：UPDATE 会锁住该行，其他事务需等待提交后才能继续
+	res, err := tx.Exec(`UPDATE datasets SET image_seq = image_seq + 1 WHERE id = ?`, datasetID)
+	if err != nil {
+		return 0, fmt.Errorf("更新图片序号失败: %w", err)
+	}
+	if err := checkAffected(res); err != nil {
+		return 0, err
+	}
+	var seq int64
+	if err := tx.QueryRow(`SELECT image_seq FROM datasets WHERE id = ?`, datasetID).Scan(&seq); err != nil {
+		return 0, fmt.Errorf("查询图片序号失败: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("提交图片序号失败: %w", err)
+	}
+	return seq, nil
+}
+
+// checkAffected 没有行受影响时返回 ErrNotFound
+func checkAffected(res sql.Result) error {
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("获取受影响行数失败: %w", err)
+	}
+	if n == 0 {
+		return ErrNotFound
+	}
+	return nil
 }
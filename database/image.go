@@ -0,0 +1,113 @@
+package database
+
+import (
+	"database/sql"
+	"dataset-sync/models"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const imageColumns = `id, dataset_id, file_name, original_name, path, size, sha256, original_sha256, width, height, format, mime_type, source, label, provenance, license, attributes, duplicate_of, ahash, dhash, phash, created_at`
+
+// scanImage 将一行查询结果读取为图片
+func scanImage(row interface{ Scan(...any) error }) (*models.Image, error) {
+	img := new(models.Image)
+	var ahash, dhash, phash sql.NullInt64
+	err := row.Scan(&img.ID, &img.DatasetID, &img.FileName, &img.OriginalName, &img.Path, &img.Size, &img.SHA256, &img.OriginalSHA256,
+		&img.Width, &img.Height, &img.Format, &img.MIMEType, &img.Source, &img.Label, &img.Provenance, &img.License, &img.Attributes, &img.DuplicateOf,
+		&ahash, &dhash, &phash, &img.CreatedAt)
+	// 数据库只支持有符号整数，感知哈希按位原样存取
+	img.Hashed = phash.Valid
+	img.AHash, img.DHash, img.PHash = uint64(ahash.Int64), uint64(dhash.Int64), uint64(phash.Int64)
+	return img, err
+}
+
+// hashArgs 感知哈希对应的参数，未计算时写入 NULL
+func hashArgs(img *models.Image) []any {
+	if !img.Hashed {
+		return []any{nil, nil, nil}
+	}
+	return []any{int64(img.AHash), int64(img.DHash), int64(img.PHash)}
+}
+
+// AddImage 写入一张图片记录
+func (r *SQLRepository) AddImage(img *models.Image) error {
+	now := time.Now()
+	hashes := hashArgs(img)
+	res, err := r.db.Exec(`INSERT INTO images (dataset_id, file_name, original_name, path, size, sha256, original_sha256, width, height, format, mime_type, source, label, provenance, license, attributes, duplicate_of,
+		ahash, dhash, phash, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		img.DatasetID, img.FileName, img.OriginalName, img.Path, img.Size, img.SHA256, img.OriginalSHA256,
+		img.Width, img.Height, img.Format, img.MIMEType, img.Source, img.Label, img.Provenance, img.License, img.Attributes, img.DuplicateOf,
+		hashes[0], hashes[1], hashes[2], now)
+	if err != nil {
+		return fmt.Errorf("写入图片记录失败: %w", err)
+	}
+	if img.ID, err = res.LastInsertId(); err != nil {
+		return fmt.Errorf("获取图片 ID 失败: %w", err)
+	}
+	img.CreatedAt = now
+	return nil
+}
+
+// FindImageBySHA256 在所有数据集中查找内容相同的最早入库图片
+func (r *SQLRepository) FindImageBySHA256(sha256 string) (*models.Image, error) {
+	img, err := scanImage(r.db.QueryRow(`SELECT `+imageColumns+` FROM images WHERE sha256 = ? OR original_sha256 = ? ORDER BY id LIMIT 1`,
+		sha256, sha256))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("查询图片失败: %w", err)
+	}
+	return img, nil
+}
+
+// ListImages 获取数据集下的全部图片
+func (r *SQLRepository) ListImages(datasetID int) ([]*models.Image, error) {
+	rows, err := r.db.Query(`SELECT `+imageColumns+` FROM images WHERE dataset_id = ? ORDER BY id`, datasetID)
+	if err != nil {
+		return nil, fmt.Errorf("查询图片失败: %w", err)
+	}
+	defer rows.Close()
+
+	var images []*models.Image
+	for rows.Next() {
+		img, err := scanImage(rows)
+		if err != nil {
+			return nil, fmt.Errorf("读取图片失败: %w", err)
+		}
+		images = append(images, img)
+	}
+	return images, rows.Err()
+}
+
+// UpdateImageHashes 更新图片的感知哈希
+func (r *SQLRepository) UpdateImageHashes(img *models.Image) error {
+	hashes := hashArgs(img)
+	res, err := r.db.Exec(`UPDATE images SET ahash = ?, dhash = ?, phash = ? WHERE id = ?`,
+		hashes[0], hashes[1], hashes[2], img.ID)
+	if err != nil {
+		return fmt.Errorf("更新感知哈希失败: %w", err)
+	}
+	return checkAffected(res)
+}
+
+// DeleteImage 删除图片记录（不删除文件）
+func (r *SQLRepository) DeleteImage(id int64) error {
+	res, err := r.db.Exec(`DELETE FROM images WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("删除图片失败: %w", err)
+	}
+	return checkAffected(res)
+}
+
+// ImagePathInUse 是否还有图片记录引用该文件（链接方式导入的重复图片共用文件）
+func (r *SQLRepository) ImagePathInUse(path string) (bool, error) {
+	var count int
+	if err := r.db.QueryRow(`SELECT COUNT(*) FROM images WHERE path = ?`, path).Scan(&count); err != nil {
+		return false, fmt.Errorf("查询图片失败: %w", err)
+	}
+	return count > 0, nil
+}
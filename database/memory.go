@@ -0,0 +1,524 @@
+package database
+
+import (
+	"dataset-sync/models"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MemoryRepository 基于内存的 DatasetRepository 实现，用于测试和无需持久化的场景
+type MemoryRepository struct {
+	mu            sync.RWMutex
+	datasets      map[int]*models.Dataset
+	images        []*models.Image
+	uploadHistory []*models.UploadDetails
+	sequences     map[int]int64 // 数据集 ID -> 图片序号
+	queue         []*models.QueueItem
+	watchFolders  []*models.WatchFolder
+	nextDatasetID int
+	nextImageID   int64
+	nextUploadID  int64
+	nextQueueID   int64
+	nextWatchID   int
+}
+
+// NewMemoryRepository 创建空的内存存储
+func NewMemoryRepository() *MemoryRepository {
+	return &MemoryRepository{
+		datasets:      make(map[int]*models.Dataset),
+		sequences:     make(map[int]int64),
+		nextDatasetID: 1,
+		nextImageID:   1,
+		nextUploadID:  1,
+		nextQueueID:   1,
+		nextWatchID:   1,
+	}
+}
+
+// CreateDataset 新增数据集
+func (r *MemoryRepository) CreateDataset(ds *models.Dataset) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, item := range r.datasets {
+		if item.Name == ds.Name {
+			return ErrDatasetExists
+		}
+	}
+	now := time.Now()
+	ds.ID = r.nextDatasetID
+	ds.ImageCount = 0
+	ds.TotalSize = 0
+	ds.DuplicateCount = 0
+	ds.CreatedAt = now
+	ds.UpdatedAt = now
+	r.nextDatasetID++
+
+	// 保存副本，避免调用方修改影响存储内容
+	stored := *ds
+	r.datasets[ds.ID] = &stored
+	return nil
+}
+
+// GetDataset 按 ID 获取数据集
+func (r *MemoryRepository) GetDataset(id int) (*models.Dataset, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	ds, ok := r.datasets[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	item := *ds
+	item.ImageCount, item.TotalSize = r.countImages(id)
+	item.DuplicateCount = r.countDuplicates(id)
+	return &item, nil
+}
+
+// ListDatasets 获取全部数据集
+func (r *MemoryRepository) ListDatasets() ([]*models.Dataset, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	datasets := make([]*models.Dataset, 0, len(r.datasets))
+	for _, ds := range r.datasets {
+		item := *ds
+		item.ImageCount, item.TotalSize = r.countImages(ds.ID)
+		item.DuplicateCount = r.countDuplicates(ds.ID)
+		datasets = append(datasets, &item)
+	}
+	sort.Slice(datasets, func(i, j int) bool { return datasets[i].ID < datasets[j].ID })
+	return datasets, nil
+}
+
+// UpdateDataset 更新数据集信息，改名为已有的名称时返回 ErrDatasetExists
+func (r *MemoryRepository) UpdateDataset(ds *models.Dataset) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.datasets[ds.ID]
+	if !ok {
+		return ErrNotFound
+	}
+	for _, item := range r.datasets {
+		if item.ID != ds.ID && item.Name == ds.Name {
+			return ErrDatasetExists
+		}
+	}
+	ds.CreatedAt, ds.Revision = stored.CreatedAt, stored.Revision
+	ds.UpdatedAt = time.Now()
+	item := *ds
+	r.datasets[ds.ID] = &item
+	return nil
+}
+
+// UpdateDatasetSettings 只更新数据集设置，存储后端变化时标记为未同步
+func (r *MemoryRepository) UpdateDatasetSettings(ds *models.Dataset) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.datasets[ds.ID]
+	if !ok {
+		return ErrNotFound
+	}
+	if stored.StorageBackend != ds.StorageBackend || stored.StorageConfig != ds.StorageConfig {
+		stored.Status = 0
+		stored.Revision++
+	}
+	stored.NormalizeFormat, stored.NormalizeQuality = ds.NormalizeFormat, ds.NormalizeQuality
+	stored.MaxImages, stored.MaxSize = ds.MaxImages, ds.MaxSize
+	stored.StorageBackend, stored.StorageConfig = ds.StorageBackend, ds.StorageConfig
+	stored.UpdatedAt = time.Now()
+	ds.UpdatedAt = stored.UpdatedAt
+	return nil
+}
+
+// MarkDatasetChanged 标记为未同步，没有封面时使用 cover
+func (r *MemoryRepository) MarkDatasetChanged(id int, cover string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.datasets[id]
+	if !ok {
+		return ErrNotFound
+	}
+	stored.Status = 0
+	stored.Revision++
+	if stored.Cover == "" {
+		stored.Cover = cover
+	}
+	stored.UpdatedAt = time.Now()
+	return nil
+}
+
+// MarkDatasetSynced revision 与当前一致时标记为已同步
+func (r *MemoryRepository) MarkDatasetSynced(id int, revision int64) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.datasets[id]
+	if !ok || stored.Revision != revision {
+		return false, nil
+	}
+	stored.Status = 1
+	stored.UpdatedAt = time.Now()
+	return true, nil
+}
+
+// DeleteDataset 删除数据集
+func (r *MemoryRepository) DeleteDataset(id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.datasets[id]; !ok {
+		return ErrNotFound
+	}
+	delete(r.datasets, id)
+	delete(r.sequences, id)
+
+	// 与数据库外键一致，级联删除图片
+	images := r.images[:0]
+	for _, img := range r.images {
+		if img.DatasetID != id {
+			images = append(images, img)
+		}
+	}
+	r.images = images
+
+	queue := r.queue[:0]
+	for _, item := range r.queue {
+		if item.DatasetID != id {
+			queue = append(queue, item)
+		}
+	}
+	r.queue = queue
+
+	folders := r.watchFolders[:0]
+	for _, folder := range r.watchFolders {
+		if folder.DatasetID != id {
+			folders = append(folders, folder)
+		}
+	}
+	r.watchFolders = folders
+	return nil
+}
+
+// NextSequence 递增并返回数据集的图片序号
+func (r *MemoryRepository) NextSequence(datasetID int) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.datasets[datasetID]; !ok {
+		return 0, ErrNotFound
+	}
+	r.sequences[datasetID]++
+	return r.sequences[datasetID], nil
+}
+
+// countImages 统计数据集下的图片数量和总大小，调用方需持有锁
+func (r *MemoryRepository) countImages(datasetID int) (int, int64) {
+	count, size := 0, int64(0)
+	for _, img := range r.images {
+		if img.DatasetID == datasetID {
+			count++
+			size += img.Size
+		}
+	}
+	return count, size
+}
+
+// countDuplicates 统计数据集上传记录中的重复数量，调用方需持有锁
+func (r *MemoryRepository) countDuplicates(datasetID int) int {
+	count := 0
+	for _, record := range r.uploadHistory {
+		if record.DatasetID == datasetID && record.Duplicate {
+			count++
+		}
+	}
+	return count
+}
+
+// AddImage 写入一张图片记录
+func (r *MemoryRepository) AddImage(img *models.Image) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.datasets[img.DatasetID]; !ok {
+		return ErrNotFound
+	}
+	img.ID = r.nextImageID
+	img.CreatedAt = time.Now()
+	r.nextImageID++
+
+	item := *img
+	r.images = append(r.images, &item)
+	return nil
+}
+
+// FindImageBySHA256 查找内容相同的最早入库图片
+func (r *MemoryRepository) FindImageBySHA256(sha256 string) (*models.Image, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, img := range r.images {
+		if img.SHA256 == sha256 || img.OriginalSHA256 == sha256 {
+			item := *img
+			return &item, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+// UpdateImageHashes 更新图片的感知哈希
+func (r *MemoryRepository) UpdateImageHashes(img *models.Image) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, stored := range r.images {
+		if stored.ID == img.ID {
+			stored.AHash, stored.DHash, stored.PHash = img.AHash, img.DHash, img.PHash
+			stored.Hashed = img.Hashed
+			return nil
+		}
+	}
+	return ErrNotFound
+}
+
+// DeleteImage 删除图片记录
+func (r *MemoryRepository) DeleteImage(id int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, img := range r.images {
+		if img.ID == id {
+			r.images = append(r.images[:i], r.images[i+1:]...)
+			return nil
+		}
+	}
+	return ErrNotFound
+}
+
+// ImagePathInUse 是否还有图片记录引用该文件
+func (r *MemoryRepository) ImagePathInUse(path string) (bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, img := range r.images {
+		if img.Path == path {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// ListImages 获取数据集下的全部图片
+func (r *MemoryRepository) ListImages(datasetID int) ([]*models.Image, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var images []*models.Image
+	for _, img := range r.images {
+		if img.DatasetID == datasetID {
+			item := *img
+			images = append(images, &item)
+		}
+	}
+	return images, nil
+}
+
+// AddUploadHistory 追加一条上传记录
+func (r *MemoryRepository) AddUploadHistory(record *models.UploadDetails) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	record.ID = r.nextUploadID
+	record.UploadTime = time.Now().Format(uploadTimeLayout)
+	r.nextUploadID++
+
+	item := *record
+	r.uploadHistory = append(r.uploadHistory, &item)
+	return nil
+}
+
+// ListUploadHistory 获取最近的上传记录，最新的在前
+func (r *MemoryRepository) ListUploadHistory(limit int) ([]*models.UploadDetails, error) {
+	return r.listUploads(func(*models.UploadDetails) bool { return true }, limit), nil
+}
+
+// ListUploadHistoryByStatus 获取指定状态的上传记录，最新的在前
+func (r *MemoryRepository) ListUploadHistoryByStatus(status models.UploadStatus, limit int) ([]*models.UploadDetails, error) {
+	return r.listUploads(func(record *models.UploadDetails) bool { return record.UploadStatus == status }, limit), nil
+}
+
+// listUploads 按上传时间倒序返回满足条件的记录，更新过的记录按更新时间排在前面
+func (r *MemoryRepository) listUploads(match func(*models.UploadDetails) bool, limit int) []*models.UploadDetails {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var uploadHistory []*models.UploadDetails
+	for i := len(r.uploadHistory) - 1; i >= 0 && len(uploadHistory) < limit; i-- {
+		if match(r.uploadHistory[i]) {
+			item := *r.uploadHistory[i]
+			uploadHistory = append(uploadHistory, &item)
+		}
+	}
+	return uploadHistory
+}
+
+// GetUploadHistory 按 ID 获取上传记录
+func (r *MemoryRepository) GetUploadHistory(id int64) (*models.UploadDetails, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, record := range r.uploadHistory {
+		if record.ID == id {
+			item := *record
+			return &item, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+// UpdateUploadHistory 更新上传记录并移到最新位置
+func (r *MemoryRepository) UpdateUploadHistory(record *models.UploadDetails) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, stored := range r.uploadHistory {
+		if stored.ID == record.ID {
+			record.UploadTime = time.Now().Format(uploadTimeLayout)
+			item := *record
+			r.uploadHistory = append(append(r.uploadHistory[:i], r.uploadHistory[i+1:]...), &item)
+			return nil
+		}
+	}
+	return ErrNotFound
+}
+
+// AddQueueItems 写入一批导入任务
+func (r *MemoryRepository) AddQueueItems(items []*models.QueueItem) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	for _, item := range items {
+		item.ID = r.nextQueueID
+		item.CreatedAt = now
+		r.nextQueueID++
+		stored := *item
+		r.queue = append(r.queue, &stored)
+	}
+	return nil
+}
+
+// ListQueueItems 获取全部未完成的导入任务，按提交顺序
+func (r *MemoryRepository) ListQueueItems() ([]*models.QueueItem, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	items := make([]*models.QueueItem, 0, len(r.queue))
+	for _, item := range r.queue {
+		stored := *item
+		items = append(items, &stored)
+	}
+	return items, nil
+}
+
+// SetQueueTarget 记录导入任务占用的保存路径
+func (r *MemoryRepository) SetQueueTarget(id int64, path string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, item := range r.queue {
+		if item.ID == id {
+			item.TargetPath = path
+			return nil
+		}
+	}
+	return ErrNotFound
+}
+
+// SetQueueDataset 修改导入任务的目标数据集
+func (r *MemoryRepository) SetQueueDataset(id int64, datasetID int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, item := range r.queue {
+		if item.ID == id {
+			item.DatasetID = datasetID
+			return nil
+		}
+	}
+	return ErrNotFound
+}
+
+// DeleteQueueItem 删除已结束的导入任务
+func (r *MemoryRepository) DeleteQueueItem(id int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, item := range r.queue {
+		if item.ID == id {
+			r.queue = append(r.queue[:i], r.queue[i+1:]...)
+			return nil
+		}
+	}
+	return ErrNotFound
+}
+
+// ListWatchFolders 获取全部监控文件夹，按 ID 升序
+func (r *MemoryRepository) ListWatchFolders() ([]*models.WatchFolder, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	folders := make([]*models.WatchFolder, 0, len(r.watchFolders))
+	for _, folder := range r.watchFolders {
+		item := *folder
+		folders = append(folders, &item)
+	}
+	return folders, nil
+}
+
+// AddWatchFolder 新增监控文件夹
+func (r *MemoryRepository) AddWatchFolder(folder *models.WatchFolder) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	folder.ID = r.nextWatchID
+	folder.CreatedAt = time.Now()
+	r.nextWatchID++
+	item := *folder
+	r.watchFolders = append(r.watchFolders, &item)
+	return nil
+}
+
+// UpdateWatchFolder 更新监控文件夹设置
+func (r *MemoryRepository) UpdateWatchFolder(folder *models.WatchFolder) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, stored := range r.watchFolders {
+		if stored.ID == folder.ID {
+			item := *folder
+			item.CreatedAt = stored.CreatedAt
+			r.watchFolders[i] = &item
+			return nil
+		}
+	}
+	return ErrNotFound
+}
+
+// DeleteWatchFolder 删除监控文件夹
+func (r *MemoryRepository) DeleteWatchFolder(id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, folder := range r.watchFolders {
+		if folder.ID == id {
+			r.watchFolders = append(r.watchFolders[:i], r.watchFolders[i+1:]...)
+			return nil
+		}
+	}
+	return ErrNotFound
+}
@@ -0,0 +1,229 @@
+package database
+
+import (
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 迁移文件按数据库类型分目录存放，文件名格式：<版本号>_<名称>.up.sql / .down.sql
+//
+//go:embed migrations
+var migrationFS embed.FS
+
+// ErrSchemaTooNew 数据库结构版本高于当前程序支持的版本（通常是被新版本程序升级过）
+var ErrSchemaTooNew = errors.New("数据库结构版本高于当前程序，请升级软件")
+
+// 记录已执行迁移的版本表
+var schemaTables = map[string]string{
+	DriverMySQL: `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version    INT          NOT NULL PRIMARY KEY,
+		name       VARCHAR(255) NOT NULL,
+		applied_at DATETIME     NOT NULL
+	) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4`,
+	DriverSQLite: `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version    INTEGER  NOT NULL PRIMARY KEY,
+		name       TEXT     NOT NULL,
+		applied_at DATETIME NOT NULL
+	)`,
+}
+
+// migration 一个版本的迁移
+type migration struct {
+	version int
+	name    string
+	up      string
+	down    string
+}
+
+// loadMigrations 读取指定数据库类型的全部迁移，按版本号升序
+func loadMigrations(driver string) ([]migration, error) {
+	dir := path.Join("migrations", driver)
+	entries, err := fs.ReadDir(migrationFS, dir)
+	if err != nil {
+		return nil, fmt.Errorf("读取迁移文件失败: %w", err)
+	}
+
+	byVersion := make(map[int]*migration)
+	for _, entry := range entries {
+		fileName := entry.Name()
+		var direction string
+		switch {
+		case strings.HasSuffix(fileName, ".up.sql"):
+			direction = "up"
+		case strings.HasSuffix(fileName, ".down.sql"):
+			direction = "down"
+		default:
+			continue
+		}
+
+		// 解析版本号与名称
+		base := strings.TrimSuffix(fileName, "."+direction+".sql")
+		versionText, name, ok := strings.Cut(base, "_")
+		if !ok {
+			return nil, fmt.Errorf("迁移文件名不合法: %s", fileName)
+		}
+		version, err := strconv.Atoi(versionText)
+		if err != nil || version <= 0 {
+			return nil, fmt.Errorf("迁移文件版本号不合法: %s", fileName)
+		}
+
+		content, err := fs.ReadFile(migrationFS, path.Join(dir, fileName))
+		if err != nil {
+			return nil, fmt.Errorf("读取迁移文件失败: %w", err)
+		}
+
+		m, exists := byVersion[version]
+		if !exists {
+			m = &migration{version: version, name: name}
+			byVersion[version] = m
+		}
+		if direction == "up" {
+			m.up = string(content)
+		} else {
+			m.down = string(content)
+		}
+	}
+
+	migrations := make([]migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.up == "" || m.down == "" {
+			return nil, fmt.Errorf("迁移 %04d_%s 缺少 up 或 down 文件", m.version, m.name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].version < migrations[j].version })
+
+	// 版本号必须从 1 开始连续编号
+	for i, m := range migrations {
+		if m.version != i+1 {
+			return nil, fmt.Errorf("迁移版本号不连续: 缺少版本 %d", i+1)
+		}
+	}
+	return migrations, nil
+}
+
+// splitStatements 按行尾分号拆分 SQL 脚本（MySQL 驱动默认不支持一次执行多条语句）
+func splitStatements(script string) []string {
+	var (
+		statements []string
+		current    strings.Builder
+	)
+	for _, line := range strings.Split(script, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "--") {
+			continue
+		}
+		current.WriteString(line)
+		current.WriteString("\n")
+		if strings.HasSuffix(trimmed, ";") {
+			statements = append(statements, strings.TrimSuffix(strings.TrimSpace(current.String()), ";"))
+			current.Reset()
+		}
+	}
+	if rest := strings.TrimSpace(current.String()); rest != "" {
+		statements = append(statements, rest)
+	}
+	return statements
+}
+
+// SchemaVersion 返回数据库当前的结构版本，未执行过迁移时为 0
+func (r *SQLRepository) SchemaVersion() (int, error) {
+	if _, err := r.db.Exec(schemaTables[r.driver]); err != nil {
+		return 0, fmt.Errorf("创建版本表失败: %w", err)
+	}
+	var version sql.NullInt64
+	if err := r.db.QueryRow(`SELECT MAX(version) FROM schema_migrations`).Scan(&version); err != nil {
+		return 0, fmt.Errorf("查询数据库版本失败: %w", err)
+	}
+	return int(version.Int64), nil
+}
+
+// Migrate 将数据库结构升级到最新版本
+// 如果数据库版本高于程序内置的最新迁移，返回 ErrSchemaTooNew，拒绝启动
+func (r *SQLRepository) Migrate() error {
+	migrations, err := loadMigrations(r.driver)
+	if err != nil {
+		return err
+	}
+	current, err := r.SchemaVersion()
+	if err != nil {
+		return err
+	}
+	latest := len(migrations)
+	if current > latest {
+		return fmt.Errorf("%w (数据库版本 %d, 程序支持 %d)", ErrSchemaTooNew, current, latest)
+	}
+
+	for _, m := range migrations[current:] {
+		fmt.Printf("执行数据库迁移 %04d_%s\n", m.version, m.name)
+		if err := r.runMigration(m, m.up, func(tx *sql.Tx) error {
+			_, err := tx.Exec(`INSERT INTO schema_migrations (version, name, applied_at) VALUES (?, ?, ?)`,
+				m.version, m.name, time.Now())
+			return err
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// MigrateDown 将数据库结构回滚到指定版本（0 表示回滚全部）
+func (r *SQLRepository) MigrateDown(target int) error {
+	migrations, err := loadMigrations(r.driver)
+	if err != nil {
+		return err
+	}
+	current, err := r.SchemaVersion()
+	if err != nil {
+		return err
+	}
+	if current > len(migrations) {
+		return fmt.Errorf("%w (数据库版本 %d, 程序支持 %d)", ErrSchemaTooNew, current, len(migrations))
+	}
+	if target < 0 || target > current {
+		return fmt.Errorf("无效的目标版本: %d", target)
+	}
+
+	for i := current - 1; i >= target; i-- {
+		m := migrations[i]
+		fmt.Printf("回滚数据库迁移 %04d_%s\n", m.version, m.name)
+		if err := r.runMigration(m, m.down, func(tx *sql.Tx) error {
+			_, err := tx.Exec(`DELETE FROM schema_migrations WHERE version = ?`, m.version)
+			return err
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// runMigration 在事务中执行迁移脚本并更新版本表
+// 注意：MySQL 的 DDL 会隐式提交，失败时可能需要人工处理
+func (r *SQLRepository) runMigration(m migration, script string, record func(tx *sql.Tx) error) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("开启事务失败: %w", err)
+	}
+	for _, stmt := range splitStatements(script) {
+		if _, err := tx.Exec(stmt); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("执行迁移 %04d_%s 失败: %w", m.version, m.name, err)
+		}
+	}
+	if err := record(tx); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("更新数据库版本失败: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("提交迁移 %04d_%s 失败: %w", m.version, m.name, err)
+	}
+	return nil
+}
@@ -0,0 +1,21 @@
+CREATE TABLE IF NOT EXISTS datasets (
+    id          INT AUTO_INCREMENT PRIMARY KEY,
+    name        VARCHAR(255)  NOT NULL UNIQUE,
+    description TEXT          NOT NULL,
+    image_count INT           NOT NULL DEFAULT 0,
+    created_at  DATETIME      NOT NULL,
+    updated_at  DATETIME      NOT NULL,
+    status      TINYINT       NOT NULL DEFAULT 0,
+    cover       VARCHAR(1024) NOT NULL DEFAULT ''
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS upload_history (
+    id            BIGINT AUTO_INCREMENT PRIMARY KEY,
+    image_name    VARCHAR(255)  NOT NULL,
+    dataset_name  VARCHAR(255)  NOT NULL,
+    image_path    VARCHAR(1024) NOT NULL,
+    image_size    VARCHAR(32)   NOT NULL,
+    upload_time   DATETIME      NOT NULL,
+    upload_status VARCHAR(32)   NOT NULL,
+    INDEX idx_upload_time (upload_time)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,5 @@
+ALTER TABLE datasets ADD COLUMN image_count INT NOT NULL DEFAULT 0;
+
+UPDATE datasets SET image_count = (SELECT COUNT(*) FROM images WHERE images.dataset_id = datasets.id);
+
+DROP TABLE IF EXISTS images;
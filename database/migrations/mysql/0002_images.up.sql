@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS images (
+    id         BIGINT AUTO_INCREMENT PRIMARY KEY,
+    dataset_id INT           NOT NULL,
+    file_name  VARCHAR(255)  NOT NULL,
+    path       VARCHAR(1024) NOT NULL,
+    size       BIGINT        NOT NULL,
+    sha256     CHAR(64)      NOT NULL,
+    width      INT           NOT NULL,
+    height     INT           NOT NULL,
+    format     VARCHAR(16)   NOT NULL,
+    mime_type  VARCHAR(64)   NOT NULL,
+    source     VARCHAR(16)   NOT NULL,
+    created_at DATETIME      NOT NULL,
+    INDEX idx_images_dataset (dataset_id),
+    INDEX idx_images_sha256 (sha256),
+    CONSTRAINT fk_images_dataset FOREIGN KEY (dataset_id) REFERENCES datasets (id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+ALTER TABLE datasets DROP COLUMN image_count;
@@ -0,0 +1 @@
+ALTER TABLE images ADD COLUMN label VARCHAR(255) NOT NULL DEFAULT '';
@@ -0,0 +1,3 @@
+ALTER TABLE upload_history DROP COLUMN duplicate;
+
+ALTER TABLE images DROP COLUMN duplicate_of;
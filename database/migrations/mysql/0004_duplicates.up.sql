@@ -0,0 +1,3 @@
+ALTER TABLE images ADD COLUMN duplicate_of BIGINT NOT NULL DEFAULT 0;
+
+ALTER TABLE upload_history ADD COLUMN duplicate TINYINT NOT NULL DEFAULT 0;
@@ -0,0 +1,5 @@
+ALTER TABLE images ADD COLUMN ahash BIGINT NULL;
+
+ALTER TABLE images ADD COLUMN dhash BIGINT NULL;
+
+ALTER TABLE images ADD COLUMN phash BIGINT NULL;
@@ -0,0 +1,3 @@
+ALTER TABLE datasets DROP COLUMN image_seq;
+
+ALTER TABLE images DROP COLUMN original_name;
@@ -0,0 +1 @@
+ALTER TABLE upload_history ADD COLUMN error_message VARCHAR(1024) NOT NULL DEFAULT '';
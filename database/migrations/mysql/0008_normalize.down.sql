@@ -0,0 +1,5 @@
+ALTER TABLE images DROP COLUMN original_sha256;
+
+ALTER TABLE datasets DROP COLUMN normalize_quality;
+
+ALTER TABLE datasets DROP COLUMN normalize_format;
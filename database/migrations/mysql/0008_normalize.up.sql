@@ -0,0 +1,11 @@
+-- 数据集的格式归一化设置，normalize_format 为空表示保留原格式
+ALTER TABLE datasets ADD COLUMN normalize_format VARCHAR(16) NOT NULL DEFAULT '';
+
+ALTER TABLE datasets ADD COLUMN normalize_quality INT NOT NULL DEFAULT 0;
+
+-- 归一化前原始文件的 SHA-256，未归一化时与 sha256 相同
+ALTER TABLE images ADD COLUMN original_sha256 CHAR(64) NOT NULL DEFAULT '';
+
+UPDATE images SET original_sha256 = sha256;
+
+CREATE INDEX idx_images_original_sha256 ON images (original_sha256);
@@ -0,0 +1,2 @@
+-- 封面改为记录封面图片的 SHA-256（通过缩略图缓存显示），原来的固定图片路径替换为数据集中最早的图片
+UPDATE datasets SET cover = COALESCE((SELECT sha256 FROM images WHERE images.dataset_id = datasets.id ORDER BY id LIMIT 1), '');
@@ -0,0 +1,17 @@
+-- 导入任务日志：未完成的任务在程序重启后继续执行，完成或取消后删除
+CREATE TABLE IF NOT EXISTS ingest_queue (
+    id          BIGINT AUTO_INCREMENT PRIMARY KEY,
+    dataset_id  INT           NOT NULL,
+    path        VARCHAR(1024) NOT NULL DEFAULT '',
+    name        VARCHAR(255)  NOT NULL,
+    sub_dir     VARCHAR(1024) NOT NULL DEFAULT '',
+    label       VARCHAR(255)  NOT NULL DEFAULT '',
+    origin      VARCHAR(2048) NOT NULL,
+    source      VARCHAR(16)   NOT NULL,
+    url         VARCHAR(2048) NOT NULL DEFAULT '',
+    duplicates  VARCHAR(16)   NOT NULL DEFAULT '',
+    temp_root   VARCHAR(1024) NOT NULL DEFAULT '',
+    target_path VARCHAR(1024) NOT NULL DEFAULT '',
+    created_at  DATETIME      NOT NULL,
+    CONSTRAINT fk_ingest_queue_dataset FOREIGN KEY (dataset_id) REFERENCES datasets (id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
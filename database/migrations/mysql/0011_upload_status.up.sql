@@ -0,0 +1,18 @@
+-- 上传状态改为固定的英文取值，原有的中文状态按含义转换，无法识别的记为失败
+UPDATE upload_history SET error_message = CONCAT('升级前状态: ', upload_status)
+    WHERE upload_status NOT IN ('成功', '失败', '重复', '已取消') AND error_message = '';
+UPDATE upload_history SET upload_status = CASE upload_status
+    WHEN '成功' THEN 'succeeded'
+    WHEN '重复' THEN 'skipped_duplicate'
+    WHEN '已取消' THEN 'cancelled'
+    ELSE 'failed' END;
+
+-- 重试需要的尝试次数、源文件路径和数据集 ID，失败记录的 image_path 即为源文件路径
+ALTER TABLE upload_history ADD COLUMN attempts INT NOT NULL DEFAULT 1;
+ALTER TABLE upload_history ADD COLUMN source_path VARCHAR(2048) NOT NULL DEFAULT '';
+ALTER TABLE upload_history ADD COLUMN dataset_id INT NOT NULL DEFAULT 0;
+UPDATE upload_history SET source_path = image_path WHERE upload_status <> 'succeeded';
+UPDATE upload_history SET dataset_id = COALESCE((SELECT id FROM datasets WHERE datasets.name = upload_history.dataset_name), 0);
+
+-- 重启后继续的重试任务更新原有的上传记录
+ALTER TABLE ingest_queue ADD COLUMN history_id BIGINT NOT NULL DEFAULT 0;
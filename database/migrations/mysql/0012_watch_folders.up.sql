@@ -0,0 +1,12 @@
+-- 数据集的监控文件夹：保存到文件夹中的图片大小稳定后自动导入
+CREATE TABLE IF NOT EXISTS watch_folders (
+    id         INT AUTO_INCREMENT PRIMARY KEY,
+    dataset_id INT           NOT NULL,
+    path       VARCHAR(1024) NOT NULL,
+    extensions VARCHAR(255)  NOT NULL DEFAULT '',
+    mode       VARCHAR(16)   NOT NULL DEFAULT 'copy',
+    enabled    BOOLEAN       NOT NULL DEFAULT TRUE,
+    last_scan  DATETIME      NULL,
+    created_at DATETIME      NOT NULL,
+    CONSTRAINT fk_watch_folders_dataset FOREIGN KEY (dataset_id) REFERENCES datasets (id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,7 @@
+-- 清单文件（CSV / JSONL）提供的图片元数据：出处、许可证和自由属性（JSON 对象）
+ALTER TABLE images ADD COLUMN provenance VARCHAR(1024) NOT NULL DEFAULT '';
+ALTER TABLE images ADD COLUMN license VARCHAR(255) NOT NULL DEFAULT '';
+ALTER TABLE images ADD COLUMN attributes TEXT NOT NULL;
+
+-- 重启后继续的任务保留清单中的元数据
+ALTER TABLE ingest_queue ADD COLUMN metadata TEXT NOT NULL;
@@ -0,0 +1,4 @@
+-- 数据集容量上限，0 表示不限制：max_images 为图片数量，max_size 为图片总大小（字节）
+ALTER TABLE datasets ADD COLUMN max_images INT NOT NULL DEFAULT 0;
+
+ALTER TABLE datasets ADD COLUMN max_size BIGINT NOT NULL DEFAULT 0;
@@ -0,0 +1,2 @@
+ALTER TABLE datasets DROP COLUMN storage_config;
+ALTER TABLE datasets DROP COLUMN storage_backend;
@@ -0,0 +1,2 @@
+-- 数据集内容或同步目标每变化一次加一，同步结束时只有期间没有变化才标记为已同步
+ALTER TABLE datasets ADD COLUMN revision BIGINT NOT NULL DEFAULT 0;
@@ -0,0 +1,2 @@
+UPDATE upload_history SET error_message = LEFT(error_message, 1024);
+ALTER TABLE upload_history MODIFY error_message VARCHAR(1024) NOT NULL DEFAULT '';
@@ -0,0 +1,2 @@
+-- 错误信息可能包含很长的链接，超过 1024 个字符时严格模式下会拒绝写入上传记录
+ALTER TABLE upload_history MODIFY error_message TEXT NOT NULL;
@@ -0,0 +1,2 @@
+UPDATE upload_history SET image_path = LEFT(image_path, 1024);
+ALTER TABLE upload_history MODIFY image_path VARCHAR(1024) NOT NULL;
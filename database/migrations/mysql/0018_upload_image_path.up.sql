@@ -0,0 +1,2 @@
+-- 失败和跳过的记录中 image_path 为原始路径或链接，与导入任务日志、source_path 一样放宽到 2048 个字符
+ALTER TABLE upload_history MODIFY image_path VARCHAR(2048) NOT NULL;
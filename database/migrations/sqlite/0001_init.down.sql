@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS upload_history;
+DROP TABLE IF EXISTS datasets;
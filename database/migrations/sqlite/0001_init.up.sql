@@ -0,0 +1,22 @@
+CREATE TABLE IF NOT EXISTS datasets (
+    id          INTEGER PRIMARY KEY AUTOINCREMENT,
+    name        TEXT     NOT NULL UNIQUE,
+    description TEXT     NOT NULL,
+    image_count INTEGER  NOT NULL DEFAULT 0,
+    created_at  DATETIME NOT NULL,
+    updated_at  DATETIME NOT NULL,
+    status      INTEGER  NOT NULL DEFAULT 0,
+    cover       TEXT     NOT NULL DEFAULT ''
+);
+
+CREATE TABLE IF NOT EXISTS upload_history (
+    id            INTEGER PRIMARY KEY AUTOINCREMENT,
+    image_name    TEXT     NOT NULL,
+    dataset_name  TEXT     NOT NULL,
+    image_path    TEXT     NOT NULL,
+    image_size    TEXT     NOT NULL,
+    upload_time   DATETIME NOT NULL,
+    upload_status TEXT     NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_upload_time ON upload_history (upload_time);
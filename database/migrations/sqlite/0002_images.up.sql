@@ -0,0 +1,20 @@
+CREATE TABLE IF NOT EXISTS images (
+    id         INTEGER PRIMARY KEY AUTOINCREMENT,
+    dataset_id INTEGER  NOT NULL REFERENCES datasets (id) ON DELETE CASCADE,
+    file_name  TEXT     NOT NULL,
+    path       TEXT     NOT NULL,
+    size       INTEGER  NOT NULL,
+    sha256     TEXT     NOT NULL,
+    width      INTEGER  NOT NULL,
+    height     INTEGER  NOT NULL,
+    format     TEXT     NOT NULL,
+    mime_type  TEXT     NOT NULL,
+    source     TEXT     NOT NULL,
+    created_at DATETIME NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_images_dataset ON images (dataset_id);
+
+CREATE INDEX IF NOT EXISTS idx_images_sha256 ON images (sha256);
+
+ALTER TABLE datasets DROP COLUMN image_count;
@@ -0,0 +1 @@
+ALTER TABLE images ADD COLUMN label TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,3 @@
+ALTER TABLE images ADD COLUMN duplicate_of INTEGER NOT NULL DEFAULT 0;
+
+ALTER TABLE upload_history ADD COLUMN duplicate INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,5 @@
+ALTER TABLE images DROP COLUMN phash;
+
+ALTER TABLE images DROP COLUMN dhash;
+
+ALTER TABLE images DROP COLUMN ahash;
@@ -0,0 +1,5 @@
+ALTER TABLE images ADD COLUMN ahash INTEGER NULL;
+
+ALTER TABLE images ADD COLUMN dhash INTEGER NULL;
+
+ALTER TABLE images ADD COLUMN phash INTEGER NULL;
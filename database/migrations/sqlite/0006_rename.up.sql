@@ -0,0 +1,9 @@
+-- 入库前的原始文件名，自动重命名后仍可追溯
+ALTER TABLE images ADD COLUMN original_name TEXT NOT NULL DEFAULT '';
+
+UPDATE images SET original_name = file_name;
+
+-- 自动重命名使用的序号，只增不减，保证删除图片后也不会重复
+ALTER TABLE datasets ADD COLUMN image_seq INTEGER NOT NULL DEFAULT 0;
+
+UPDATE datasets SET image_seq = (SELECT COUNT(*) FROM images WHERE images.dataset_id = datasets.id);
@@ -0,0 +1 @@
+ALTER TABLE upload_history DROP COLUMN error_message;
@@ -0,0 +1 @@
+ALTER TABLE upload_history ADD COLUMN error_message TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_images_original_sha256;
+
+ALTER TABLE images DROP COLUMN original_sha256;
+
+ALTER TABLE datasets DROP COLUMN normalize_quality;
+
+ALTER TABLE datasets DROP COLUMN normalize_format;
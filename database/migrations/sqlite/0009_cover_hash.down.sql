@@ -0,0 +1 @@
+UPDATE datasets SET cover = '';
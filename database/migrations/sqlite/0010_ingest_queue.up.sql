@@ -0,0 +1,16 @@
+-- 导入任务日志：未完成的任务在程序重启后继续执行，完成或取消后删除
+CREATE TABLE IF NOT EXISTS ingest_queue (
+    id          INTEGER PRIMARY KEY AUTOINCREMENT,
+    dataset_id  INTEGER  NOT NULL REFERENCES datasets (id) ON DELETE CASCADE,
+    path        TEXT     NOT NULL DEFAULT '',
+    name        TEXT     NOT NULL,
+    sub_dir     TEXT     NOT NULL DEFAULT '',
+    label       TEXT     NOT NULL DEFAULT '',
+    origin      TEXT     NOT NULL,
+    source      TEXT     NOT NULL,
+    url         TEXT     NOT NULL DEFAULT '',
+    duplicates  TEXT     NOT NULL DEFAULT '',
+    temp_root   TEXT     NOT NULL DEFAULT '',
+    target_path TEXT     NOT NULL DEFAULT '',
+    created_at  DATETIME NOT NULL
+);
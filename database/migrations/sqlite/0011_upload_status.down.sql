@@ -0,0 +1,9 @@
+ALTER TABLE ingest_queue DROP COLUMN history_id;
+ALTER TABLE upload_history DROP COLUMN dataset_id;
+ALTER TABLE upload_history DROP COLUMN source_path;
+ALTER TABLE upload_history DROP COLUMN attempts;
+UPDATE upload_history SET upload_status = CASE upload_status
+    WHEN 'succeeded' THEN '成功'
+    WHEN 'skipped_duplicate' THEN '重复'
+    WHEN 'cancelled' THEN '已取消'
+    ELSE '失败' END;
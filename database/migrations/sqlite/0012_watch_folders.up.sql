@@ -0,0 +1,11 @@
+-- 数据集的监控文件夹：保存到文件夹中的图片大小稳定后自动导入
+CREATE TABLE IF NOT EXISTS watch_folders (
+    id         INTEGER PRIMARY KEY AUTOINCREMENT,
+    dataset_id INTEGER  NOT NULL REFERENCES datasets (id) ON DELETE CASCADE,
+    path       TEXT     NOT NULL,
+    extensions TEXT     NOT NULL DEFAULT '',
+    mode       TEXT     NOT NULL DEFAULT 'copy',
+    enabled    INTEGER  NOT NULL DEFAULT 1,
+    last_scan  DATETIME NULL,
+    created_at DATETIME NOT NULL
+);
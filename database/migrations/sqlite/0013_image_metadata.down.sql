@@ -0,0 +1,4 @@
+ALTER TABLE ingest_queue DROP COLUMN metadata;
+ALTER TABLE images DROP COLUMN attributes;
+ALTER TABLE images DROP COLUMN license;
+ALTER TABLE images DROP COLUMN provenance;
@@ -0,0 +1,2 @@
+ALTER TABLE datasets DROP COLUMN max_size;
+ALTER TABLE datasets DROP COLUMN max_images;
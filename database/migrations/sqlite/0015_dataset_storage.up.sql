@@ -0,0 +1,4 @@
+-- 数据集同步到的存储后端：storage_backend 为后端类型，为空表示不同步；storage_config 为后端设置（JSON 对象）
+ALTER TABLE datasets ADD COLUMN storage_backend TEXT NOT NULL DEFAULT '';
+
+ALTER TABLE datasets ADD COLUMN storage_config TEXT NOT NULL DEFAULT '';
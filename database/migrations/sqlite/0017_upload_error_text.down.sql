@@ -0,0 +1 @@
+-- SQLite 的 TEXT 不限制长度，无需回滚
@@ -0,0 +1 @@
+-- SQLite 的 TEXT 不限制长度，与 MySQL 保持相同的版本号
@@ -0,0 +1,45 @@
+package database
+
+import (
+	"database/sql"
+	"dataset-sync/conf"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// openMySQL 根据配置打开 MySQL 连接池
+func openMySQL(cfg *conf.MySQLConfig) (*sql.DB, error) {
+	if cfg == nil {
+		return nil, errors.New("缺少 MySQL 配置")
+	}
+
+	// 使用驱动自带的 Config 拼接 DSN，避免密码中的特殊字符出错
+	dsn := mysql.NewConfig()
+	dsn.User = cfg.User
+	dsn.Passwd = cfg.Password
+	dsn.Net = "tcp"
+	dsn.Addr = fmt.Sprintf("%s:%d", cfg.Host, cfg.Port)
+	dsn.DBName = cfg.DB
+	dsn.ParseTime = true
+	dsn.ClientFoundRows = true // UPDATE 返回匹配行数，与 SQLite 行为一致
+	dsn.Loc = time.Local
+	dsn.Params = map[string]string{"charset": "utf8mb4"}
+
+	conn, err := sql.Open("mysql", dsn.FormatDSN())
+	if err != nil {
+		return nil, fmt.Errorf("打开 MySQL 连接失败: %w", err)
+	}
+	// 连接池设置
+	conn.SetMaxOpenConns(cfg.MaxOpenConns)
+	conn.SetMaxIdleConns(cfg.MaxIdleConns)
+	conn.SetConnMaxLifetime(time.Hour)
+
+	if err = conn.Ping(); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("连接 MySQL 失败: %w", err)
+	}
+	return conn, nil
+}
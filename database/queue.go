@@ -0,0 +1,89 @@
+package database
+
+import (
+	"dataset-sync/models"
+	"fmt"
+	"time"
+)
+
+const queueColumns = `id, dataset_id, path, name, sub_dir, label, origin, source, url, duplicates, temp_root, target_path, history_id, metadata, created_at`
+
+// AddQueueItems 在一个事务中写入一批导入任务并回填 ID
+func (r *SQLRepository) AddQueueItems(items []*models.QueueItem) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("写入导入任务失败: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`INSERT INTO ingest_queue (dataset_id, path, name, sub_dir, label, origin, source, url, duplicates, temp_root, target_path, history_id, metadata, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return fmt.Errorf("写入导入任务失败: %w", err)
+	}
+	defer stmt.Close()
+
+	now := time.Now()
+	for _, item := range items {
+		res, err := stmt.Exec(item.DatasetID, item.Path, item.Name, item.SubDir, item.Label, item.Origin,
+			item.Source, item.URL, item.Duplicates, item.TempRoot, item.TargetPath, item.HistoryID, item.Metadata, now)
+		if err != nil {
+			return fmt.Errorf("写入导入任务失败: %w", err)
+		}
+		if item.ID, err = res.LastInsertId(); err != nil {
+			return fmt.Errorf("获取导入任务 ID 失败: %w", err)
+		}
+		item.CreatedAt = now
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("写入导入任务失败: %w", err)
+	}
+	return nil
+}
+
+// ListQueueItems 获取全部未完成的导入任务，按提交顺序
+func (r *SQLRepository) ListQueueItems() ([]*models.QueueItem, error) {
+	rows, err := r.db.Query(`SELECT ` + queueColumns + ` FROM ingest_queue ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("查询导入任务失败: %w", err)
+	}
+	defer rows.Close()
+
+	var items []*models.QueueItem
+	for rows.Next() {
+		item := new(models.QueueItem)
+		if err := rows.Scan(&item.ID, &item.DatasetID, &item.Path, &item.Name, &item.SubDir, &item.Label, &item.Origin,
+			&item.Source, &item.URL, &item.Duplicates, &item.TempRoot, &item.TargetPath, &item.HistoryID, &item.Metadata, &item.CreatedAt); err != nil {
+			return nil, fmt.Errorf("读取导入任务失败: %w", err)
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+// SetQueueTarget 记录导入任务占用的保存路径
+func (r *SQLRepository) SetQueueTarget(id int64, path string) error {
+	res, err := r.db.Exec(`UPDATE ingest_queue SET target_path = ? WHERE id = ?`, path, id)
+	if err != nil {
+		return fmt.Errorf("更新导入任务失败: %w", err)
+	}
+	return checkAffected(res)
+}
+
+// SetQueueDataset 修改导入任务的目标数据集
+func (r *SQLRepository) SetQueueDataset(id int64, datasetID int) error {
+	res, err := r.db.Exec(`UPDATE ingest_queue SET dataset_id = ? WHERE id = ?`, datasetID, id)
+	if err != nil {
+		return fmt.Errorf("更新导入任务失败: %w", err)
+	}
+	return checkAffected(res)
+}
+
+// DeleteQueueItem 删除已结束的导入任务
+func (r *SQLRepository) DeleteQueueItem(id int64) error {
+	res, err := r.db.Exec(`DELETE FROM ingest_queue WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("删除导入任务失败: %w", err)
+	}
+	return checkAffected(res)
+}
@@ -0,0 +1,84 @@
+package database
+
+import (
+	"dataset-sync/models"
+	"errors"
+)
+
+var (
+	ErrNotFound      = errors.New("记录不存在")
+	ErrDatasetExists = errors.New("数据集名称已存在")
+)
+
+// DatasetRepository 数据集与上传记录的存储接口
+// UI、命令行以及测试都只依赖该接口，具体实现可以是 MySQL、SQLite 或内存
+type DatasetRepository interface {
+	// CreateDataset 新增数据集，成功后回填 ID 与创建、更新时间
+	CreateDataset(ds *models.Dataset) error
+	// GetDataset 按 ID 获取数据集，不存在时返回 ErrNotFound
+	GetDataset(id int) (*models.Dataset, error)
+	// ListDatasets 获取全部数据集，按 ID 升序
+	ListDatasets() ([]*models.Dataset, error)
+	// UpdateDataset 更新数据集信息并刷新更新时间，不存在时返回 ErrNotFound，改名为已有的名称时返回 ErrDatasetExists
+	UpdateDataset(ds *models.Dataset) error
+	// UpdateDatasetSettings 只更新格式归一化、容量上限和存储后端设置并刷新更新时间，存储后端变化时标记为未同步并增加 revision
+	UpdateDatasetSettings(ds *models.Dataset) error
+	// MarkDatasetChanged 数据集内容有变化：标记为未同步并增加 revision，没有封面时使用 cover，不修改其他设置
+	MarkDatasetChanged(id int, cover string) error
+	// MarkDatasetSynced 同步结束后标记为已同步，只有 revision 与同步开始时读取的一致（期间没有变化）才更新，返回是否已标记
+	MarkDatasetSynced(id int, revision int64) (bool, error)
+	// DeleteDataset 删除数据集，不存在时返回 ErrNotFound
+	DeleteDataset(id int) error
+	// NextSequence 原子地递增并返回数据集的图片序号，用于自动重命名
+	NextSequence(datasetID int) (int64, error)
+
+	// AddImage 写入一张图片记录，成功后回填 ID 与入库时间
+	AddImage(img *models.Image) error
+	// ListImages 获取数据集下的全部图片，按 ID 升序
+	ListImages(datasetID int) ([]*models.Image, error)
+	// FindImageBySHA256 在所有数据集中查找内容相同（归一化前或归一化后）的最早入库图片，不存在时返回 ErrNotFound
+	FindImageBySHA256(sha256 string) (*models.Image, error)
+	// UpdateImageHashes 更新图片的感知哈希
+	UpdateImageHashes(img *models.Image) error
+	// DeleteImage 删除图片记录，不删除文件，不存在时返回 ErrNotFound
+	DeleteImage(id int64) error
+	// ImagePathInUse 是否还有图片记录引用该文件
+	ImagePathInUse(path string) (bool, error)
+
+	// AddUploadHistory 追加一条上传记录，成功后回填 ID 与上传时间
+	AddUploadHistory(record *models.UploadDetails) error
+	// ListUploadHistory 获取最近的 limit 条上传记录，按时间倒序
+	ListUploadHistory(limit int) ([]*models.UploadDetails, error)
+	// ListUploadHistoryByStatus 获取指定状态的最近 limit 条上传记录，按时间倒序
+	ListUploadHistoryByStatus(status models.UploadStatus, limit int) ([]*models.UploadDetails, error)
+	// GetUploadHistory 按 ID 获取上传记录，不存在时返回 ErrNotFound
+	GetUploadHistory(id int64) (*models.UploadDetails, error)
+	// UpdateUploadHistory 更新上传记录并刷新上传时间，不存在时返回 ErrNotFound
+	UpdateUploadHistory(record *models.UploadDetails) error
+
+	// AddQueueItems 写入一批待导入任务（导入任务日志），成功后回填 ID
+	AddQueueItems(items []*models.QueueItem) error
+	// ListQueueItems 获取全部未完成的导入任务，按提交顺序
+	ListQueueItems() ([]*models.QueueItem, error)
+	// SetQueueTarget 记录任务占用的保存路径，不存在时返回 ErrNotFound
+	SetQueueTarget(id int64, path string) error
+	// SetQueueDataset 修改尚未开始的任务的目标数据集，不存在时返回 ErrNotFound
+	SetQueueDataset(id int64, datasetID int) error
+	// DeleteQueueItem 删除已结束的任务，不存在时返回 ErrNotFound
+	DeleteQueueItem(id int64) error
+
+	// ListWatchFolders 获取全部监控文件夹，按 ID 升序
+	ListWatchFolders() ([]*models.WatchFolder, error)
+	// AddWatchFolder 新增监控文件夹，成功后回填 ID 与创建时间
+	AddWatchFolder(folder *models.WatchFolder) error
+	// UpdateWatchFolder 更新监控文件夹，不存在时返回 ErrNotFound
+	UpdateWatchFolder(folder *models.WatchFolder) error
+	// DeleteWatchFolder 删除监控文件夹，不存在时返回 ErrNotFound
+	DeleteWatchFolder(id int) error
+}
+
+// 编译期检查实现是否满足接口
+var (
+	_ DatasetRepository = (*SQLRepository)(nil)
+	_ DatasetRepository = (*MemoryRepository)(nil)
+)
@@ -0,0 +1,252 @@
+package database
+
+import (
+	"dataset-sync/conf"
+	"dataset-sync/models"
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+// testRepositories 需要满足同一契约的全部实现：内存与 SQLite
+func testRepositories(t *testing.T) map[string]func(t *testing.T) DatasetRepository {
+	return map[string]func(t *testing.T) DatasetRepository{
+		"memory": func(t *testing.T) DatasetRepository {
+			return NewMemoryRepository()
+		},
+		"sqlite": func(t *testing.T) DatasetRepository {
+			repo, err := Open(&conf.DatabaseConfig{Driver: DriverSQLite, SQLitePath: filepath.Join(t.TempDir(), "test.db")}, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			t.Cleanup(func() { repo.Close() })
+			if err := repo.Migrate(); err != nil {
+				t.Fatal(err)
+			}
+			return repo
+		},
+	}
+}
+
+// createDataset 新建数据集，失败时终止测试
+func createDataset(t *testing.T, repo DatasetRepository, name string) *models.Dataset {
+	t.Helper()
+	ds := &models.Dataset{Name: name}
+	if err := repo.CreateDataset(ds); err != nil {
+		t.Fatal(err)
+	}
+	return ds
+}
+
+func TestRepositoryContract(t *testing.T) {
+	tests := []struct {
+		name string
+		run  func(t *testing.T, repo DatasetRepository)
+	}{
+		{"duplicate names", func(t *testing.T, repo DatasetRepository) {
+			createDataset(t, repo, "cats")
+			dogs := createDataset(t, repo, "dogs")
+			if err := repo.CreateDataset(&models.Dataset{Name: "cats"}); !errors.Is(err, ErrDatasetExists) {
+				t.Fatalf("create err = %v, want ErrDatasetExists", err)
+			}
+			dogs.Name = "cats"
+			if err := repo.UpdateDataset(dogs); !errors.Is(err, ErrDatasetExists) {
+				t.Fatalf("rename err = %v, want ErrDatasetExists", err)
+			}
+			if got, _ := repo.GetDataset(dogs.ID); got.Name != "dogs" {
+				t.Fatalf("name = %q after a rejected rename", got.Name)
+			}
+			// 保存时名称不变不算冲突
+			dogs.Name = "dogs"
+			dogs.Description = "good boys"
+			if err := repo.UpdateDataset(dogs); err != nil {
+				t.Fatal(err)
+			}
+		}},
+		{"missing rows", func(t *testing.T, repo DatasetRepository) {
+			if _, err := repo.GetDataset(42); !errors.Is(err, ErrNotFound) {
+				t.Fatalf("GetDataset err = %v, want ErrNotFound", err)
+			}
+			if err := repo.UpdateDataset(&models.Dataset{ID: 42, Name: "x"}); !errors.Is(err, ErrNotFound) {
+				t.Fatalf("UpdateDataset err = %v, want ErrNotFound", err)
+			}
+			if err := repo.UpdateDatasetSettings(&models.Dataset{ID: 42}); !errors.Is(err, ErrNotFound) {
+				t.Fatalf("UpdateDatasetSettings err = %v, want ErrNotFound", err)
+			}
+			if err := repo.DeleteDataset(42); !errors.Is(err, ErrNotFound) {
+				t.Fatalf("DeleteDataset err = %v, want ErrNotFound", err)
+			}
+			if err := repo.DeleteImage(42); !errors.Is(err, ErrNotFound) {
+				t.Fatalf("DeleteImage err = %v, want ErrNotFound", err)
+			}
+			if _, err := repo.FindImageBySHA256("none"); !errors.Is(err, ErrNotFound) {
+				t.Fatalf("FindImageBySHA256 err = %v, want ErrNotFound", err)
+			}
+			if _, err := repo.GetUploadHistory(42); !errors.Is(err, ErrNotFound) {
+				t.Fatalf("GetUploadHistory err = %v, want ErrNotFound", err)
+			}
+			if err := repo.SetQueueTarget(42, "a.png"); !errors.Is(err, ErrNotFound) {
+				t.Fatalf("SetQueueTarget err = %v, want ErrNotFound", err)
+			}
+			if err := repo.DeleteQueueItem(42); !errors.Is(err, ErrNotFound) {
+				t.Fatalf("DeleteQueueItem err = %v, want ErrNotFound", err)
+			}
+		}},
+		{"settings keep status and cover", func(t *testing.T, repo DatasetRepository) {
+			ds := createDataset(t, repo, "cats")
+			if err := repo.MarkDatasetChanged(ds.ID, "cover-sha"); err != nil {
+				t.Fatal(err)
+			}
+			got, _ := repo.GetDataset(ds.ID)
+			if ok, err := repo.MarkDatasetSynced(ds.ID, got.Revision); err != nil || !ok {
+				t.Fatalf("MarkDatasetSynced = %v, %v", ok, err)
+			}
+
+			// 设置窗口打开时读到的是旧的状态与封面
+			ds.MaxImages = 10
+			if err := repo.UpdateDatasetSettings(ds); err != nil {
+				t.Fatal(err)
+			}
+			got, _ = repo.GetDataset(ds.ID)
+			if got.MaxImages != 10 || got.Status != 1 || got.Cover != "cover-sha" {
+				t.Fatalf("max=%d status=%d cover=%q, want the settings saved and status/cover kept", got.MaxImages, got.Status, got.Cover)
+			}
+
+			// 修改存储后端需要重新同步
+			ds.StorageBackend = "local"
+			if err := repo.UpdateDatasetSettings(ds); err != nil {
+				t.Fatal(err)
+			}
+			if after, _ := repo.GetDataset(ds.ID); after.Status != 0 || after.Revision == got.Revision {
+				t.Fatalf("status=%d revision=%d, want unsynced with a new revision", after.Status, after.Revision)
+			}
+		}},
+		{"sync revision", func(t *testing.T, repo DatasetRepository) {
+			ds := createDataset(t, repo, "cats")
+			start, _ := repo.GetDataset(ds.ID)
+			if err := repo.MarkDatasetChanged(ds.ID, "first"); err != nil {
+				t.Fatal(err)
+			}
+			if err := repo.MarkDatasetChanged(ds.ID, "second"); err != nil {
+				t.Fatal(err)
+			}
+			if ok, err := repo.MarkDatasetSynced(ds.ID, start.Revision); err != nil || ok {
+				t.Fatalf("MarkDatasetSynced with a stale revision = %v, %v, want false", ok, err)
+			}
+			got, _ := repo.GetDataset(ds.ID)
+			if got.Status != 0 || got.Cover != "first" {
+				t.Fatalf("status=%d cover=%q, want unsynced with the first cover", got.Status, got.Cover)
+			}
+			if ok, err := repo.MarkDatasetSynced(ds.ID, got.Revision); err != nil || !ok {
+				t.Fatalf("MarkDatasetSynced = %v, %v, want true", ok, err)
+			}
+		}},
+		{"next sequence", func(t *testing.T, repo DatasetRepository) {
+			cats := createDataset(t, repo, "cats")
+			dogs := createDataset(t, repo, "dogs")
+			for want := int64(1); want <= 3; want++ {
+				if got, err := repo.NextSequence(cats.ID); err != nil || got != want {
+					t.Fatalf("NextSequence = %d, %v, want %d", got, err, want)
+				}
+			}
+			if got, _ := repo.NextSequence(dogs.ID); got != 1 {
+				t.Fatalf("NextSequence of another dataset = %d, want 1", got)
+			}
+		}},
+		{"images", func(t *testing.T, repo DatasetRepository) {
+			ds := createDataset(t, repo, "cats")
+			first := &models.Image{DatasetID: ds.ID, FileName: "a.jpg", Path: "/data/cats/a.jpg", Size: 3, SHA256: "converted", OriginalSHA256: "original"}
+			second := &models.Image{DatasetID: ds.ID, FileName: "b.jpg", Path: "/data/cats/a.jpg", Size: 3, SHA256: "converted", OriginalSHA256: "converted"}
+			for _, img := range []*models.Image{first, second} {
+				if err := repo.AddImage(img); err != nil {
+					t.Fatal(err)
+				}
+			}
+			for _, sha := range []string{"converted", "original"} {
+				if got, err := repo.FindImageBySHA256(sha); err != nil || got.ID != first.ID {
+					t.Fatalf("FindImageBySHA256(%s) = %v, %v, want the earliest image", sha, got, err)
+				}
+			}
+			if got, _ := repo.GetDataset(ds.ID); got.ImageCount != 2 || got.TotalSize != 6 {
+				t.Fatalf("count=%d size=%d, want 2 and 6", got.ImageCount, got.TotalSize)
+			}
+
+			// 还有记录引用同一个文件时不能删除文件
+			if err := repo.DeleteImage(first.ID); err != nil {
+				t.Fatal(err)
+			}
+			if used, err := repo.ImagePathInUse(first.Path); err != nil || !used {
+				t.Fatalf("ImagePathInUse = %v, %v, want true", used, err)
+			}
+			if err := repo.DeleteImage(second.ID); err != nil {
+				t.Fatal(err)
+			}
+			if used, err := repo.ImagePathInUse(first.Path); err != nil || used {
+				t.Fatalf("ImagePathInUse = %v, %v, want false", used, err)
+			}
+		}},
+		{"upload history", func(t *testing.T, repo DatasetRepository) {
+			failed := &models.UploadDetails{ImageName: "a.png", UploadStatus: models.UploadFailed, ErrorMessage: "解码失败", Attempts: 1}
+			done := &models.UploadDetails{ImageName: "b.png", UploadStatus: models.UploadSucceeded, Attempts: 1}
+			for _, record := range []*models.UploadDetails{failed, done} {
+				if err := repo.AddUploadHistory(record); err != nil {
+					t.Fatal(err)
+				}
+			}
+			list, err := repo.ListUploadHistoryByStatus(models.UploadFailed, 10)
+			if err != nil || len(list) != 1 || list[0].ID != failed.ID {
+				t.Fatalf("ListUploadHistoryByStatus = %v, %v, want only the failed record", list, err)
+			}
+
+			failed.UploadStatus = models.UploadSucceeded
+			failed.Attempts = 2
+			if err := repo.UpdateUploadHistory(failed); err != nil {
+				t.Fatal(err)
+			}
+			got, err := repo.GetUploadHistory(failed.ID)
+			if err != nil || got.UploadStatus != models.UploadSucceeded || got.Attempts != 2 {
+				t.Fatalf("GetUploadHistory = %+v, %v, want the retried record", got, err)
+			}
+			if list, _ := repo.ListUploadHistory(1); len(list) != 1 {
+				t.Fatalf("ListUploadHistory(1) returned %d records", len(list))
+			}
+		}},
+		{"queue items", func(t *testing.T, repo DatasetRepository) {
+			cats := createDataset(t, repo, "cats")
+			dogs := createDataset(t, repo, "dogs")
+			items := []*models.QueueItem{
+				{DatasetID: cats.ID, Path: "/tmp/a.png", Name: "a.png", Source: models.SourceFile},
+				{DatasetID: cats.ID, Path: "/tmp/b.png", Name: "b.png", Source: models.SourceFile},
+			}
+			if err := repo.AddQueueItems(items); err != nil {
+				t.Fatal(err)
+			}
+			if err := repo.SetQueueTarget(items[0].ID, "/data/cats/a.png"); err != nil {
+				t.Fatal(err)
+			}
+			if err := repo.SetQueueDataset(items[1].ID, dogs.ID); err != nil {
+				t.Fatal(err)
+			}
+			if err := repo.DeleteQueueItem(items[0].ID); err != nil {
+				t.Fatal(err)
+			}
+			list, err := repo.ListQueueItems()
+			if err != nil || len(list) != 1 {
+				t.Fatalf("ListQueueItems = %v, %v, want one item", list, err)
+			}
+			if list[0].ID != items[1].ID || list[0].DatasetID != dogs.ID {
+				t.Fatalf("item = %+v, want the second item moved to dogs", list[0])
+			}
+		}},
+	}
+
+	for name, open := range testRepositories(t) {
+		t.Run(name, func(t *testing.T) {
+			for _, tt := range tests {
+				t.Run(tt.name, func(t *testing.T) {
+					tt.run(t, open(t))
+				})
+			}
+		})
+	}
+}
@@ -0,0 +1,37 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+const defaultSQLitePath = "data/dataset-sync.db" // 未配置路径时使用的本地数据库文件
+
+// openSQLite 打开本地 SQLite 数据库文件，不存在时自动创建
+func openSQLite(path string) (*sql.DB, error) {
+	if path == "" {
+		path = defaultSQLitePath
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("创建数据库目录失败: %w", err)
+	}
+
+	// WAL 模式 + 忙等待，外键约束默认开启
+	dsn := fmt.Sprintf("file:%s?_loc=auto&_journal_mode=WAL&_busy_timeout=5000&_foreign_keys=1", filepath.ToSlash(path))
+	conn, err := sql.Open("sqlite3", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("打开 SQLite 数据库失败: %w", err)
+	}
+	// SQLite 同一时刻只允许一个写入者，单连接避免 database is locked
+	conn.SetMaxOpenConns(1)
+
+	if err = conn.Ping(); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("连接 SQLite 数据库失败: %w", err)
+	}
+	return conn, nil
+}
@@ -1,30 +1,100 @@
 package database
 
 import (
+	"database/sql"
 	"dataset-sync/models"
-	"strconv"
+	"errors"
+	"fmt"
+	"time"
 )
 
-// 获取上传历史记录
-func GetUploadHistory() []*models.UploadDetails {
-	// 模拟数据
+const uploadTimeLayout = "2006-01-02 15:04:05" // 上传时间显示格式
+
+const uploadColumns = `id, dataset_id, image_name, dataset_name, image_path, image_size, upload_time, upload_status, duplicate, error_message, attempts, source_path`
+
+// scanUpload 读取一行上传记录
+func scanUpload(row interface{ Scan(...any) error }) (*models.UploadDetails, error) {
+	record := new(models.UploadDetails)
+	var uploadTime time.Time
+	if err := row.Scan(&record.ID, &record.DatasetID, &record.ImageName, &record.DatasetName, &record.ImagePath,
+		&record.ImageSize, &uploadTime, &record.UploadStatus, &record.Duplicate, &record.ErrorMessage,
+		&record.Attempts, &record.SourcePath); err != nil {
+		return nil, err
+	}
+	record.UploadTime = uploadTime.Format(uploadTimeLayout)
+	return record, nil
+}
+
+// queryUploads 执行查询并读取全部上传记录
+func (r *SQLRepository) queryUploads(query string, args ...any) ([]*models.UploadDetails, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("查询上传记录失败: %w", err)
+	}
+	defer rows.Close()
+
 	var uploadHistory []*models.UploadDetails
-	for i := 0; i < 200; i++ {
-		// 使用rand包来随机生成
-		uploadHistory = append(uploadHistory, &models.UploadDetails{
-			ImageName:   "image" + strconv.Itoa(i),
-			DatasetName: strconv.Itoa(i),
-			ImagePath:   "path/to/image" + strconv.Itoa(i),
-			ImageSize:   strconv.Itoa(i) + "MB",
-			UploadTime:  "2023-10-01 12:00:00",
-			// 状态根据随机值确定
-			UploadStatus: func() string {
-				if i%2 == 0 {
-					return "成功"
-				}
-				return "失败"
-			}(),
-		})
-	}
-	return uploadHistory
+	for rows.Next() {
+		record, err := scanUpload(rows)
+		if err != nil {
+			return nil, fmt.Errorf("读取上传记录失败: %w", err)
+		}
+		uploadHistory = append(uploadHistory, record)
+	}
+	return uploadHistory, rows.Err()
+}
+
+// ListUploadHistory 获取最近的上传历史记录
+func (r *SQLRepository) ListUploadHistory(limit int) ([]*models.UploadDetails, error) {
+	return r.queryUploads(`SELECT `+uploadColumns+` FROM upload_history ORDER BY upload_time DESC, id DESC LIMIT ?`, limit)
+}
+
+// ListUploadHistoryByStatus 获取指定状态的上传记录，最新的在前
+func (r *SQLRepository) ListUploadHistoryByStatus(status models.UploadStatus, limit int) ([]*models.UploadDetails, error) {
+	return r.queryUploads(`SELECT `+uploadColumns+` FROM upload_history WHERE upload_status = ?
+		ORDER BY upload_time DESC, id DESC LIMIT ?`, status, limit)
+}
+
+// GetUploadHistory 按 ID 获取上传记录
+func (r *SQLRepository) GetUploadHistory(id int64) (*models.UploadDetails, error) {
+	record, err := scanUpload(r.db.QueryRow(`SELECT `+uploadColumns+` FROM upload_history WHERE id = ?`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("查询上传记录失败: %w", err)
+	}
+	return record, nil
+}
+
+// AddUploadHistory 写入一条上传记录
+func (r *SQLRepository) AddUploadHistory(record *models.UploadDetails) error {
+	now := time.Now()
+	res, err := r.db.Exec(`INSERT INTO upload_history (dataset_id, image_name, dataset_name, image_path, image_size, upload_time,
+		upload_status, duplicate, error_message, attempts, source_path)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		record.DatasetID, record.ImageName, record.DatasetName, record.ImagePath, record.ImageSize, now,
+		record.UploadStatus, record.Duplicate, record.ErrorMessage, record.Attempts, record.SourcePath)
+	if err != nil {
+		return fmt.Errorf("写入上传记录失败: %w", err)
+	}
+	if record.ID, err = res.LastInsertId(); err != nil {
+		return fmt.Errorf("获取上传记录 ID 失败: %w", err)
+	}
+	record.UploadTime = now.Format(uploadTimeLayout)
+	return nil
+}
+
+// UpdateUploadHistory 更新上传记录（重试时复用原记录），上传时间刷新为当前时间
+func (r *SQLRepository) UpdateUploadHistory(record *models.UploadDetails) error {
+	now := time.Now()
+	res, err := r.db.Exec(`UPDATE upload_history SET dataset_id = ?, image_name = ?, dataset_name = ?, image_path = ?, image_size = ?,
+		upload_time = ?, upload_status = ?, duplicate = ?, error_message = ?, attempts = ?, source_path = ? WHERE id = ?`,
+		record.DatasetID, record.ImageName, record.DatasetName, record.ImagePath, record.ImageSize, now,
+		record.UploadStatus, record.Duplicate, record.ErrorMessage, record.Attempts, record.SourcePath, record.ID)
+	if err != nil {
+		return fmt.Errorf("更新上传记录失败: %w", err)
+	}
+	record.UploadTime = now.Format(uploadTimeLayout)
+	return checkAffected(res)
 }
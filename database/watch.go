@@ -0,0 +1,74 @@
+package database
+
+import (
+	"database/sql"
+	"dataset-sync/models"
+	"fmt"
+	"time"
+)
+
+const watchColumns = `id, dataset_id, path, extensions, mode, enabled, last_scan, created_at`
+
+// ListWatchFolders 获取全部监控文件夹，按 ID 升序
+func (r *SQLRepository) ListWatchFolders() ([]*models.WatchFolder, error) {
+	rows, err := r.db.Query(`SELECT ` + watchColumns + ` FROM watch_folders ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("查询监控文件夹失败: %w", err)
+	}
+	defer rows.Close()
+
+	var folders []*models.WatchFolder
+	for rows.Next() {
+		folder := new(models.WatchFolder)
+		var lastScan sql.NullTime
+		if err := rows.Scan(&folder.ID, &folder.DatasetID, &folder.Path, &folder.Extensions, &folder.Mode,
+			&folder.Enabled, &lastScan, &folder.CreatedAt); err != nil {
+			return nil, fmt.Errorf("读取监控文件夹失败: %w", err)
+		}
+		folder.LastScan = lastScan.Time
+		folders = append(folders, folder)
+	}
+	return folders, rows.Err()
+}
+
+// AddWatchFolder 新增监控文件夹，成功后回填 ID 和创建时间
+func (r *SQLRepository) AddWatchFolder(folder *models.WatchFolder) error {
+	now := time.Now()
+	res, err := r.db.Exec(`INSERT INTO watch_folders (dataset_id, path, extensions, mode, enabled, last_scan, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		folder.DatasetID, folder.Path, folder.Extensions, folder.Mode, folder.Enabled, nullTime(folder.LastScan), now)
+	if err != nil {
+		return fmt.Errorf("新增监控文件夹失败: %w", err)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("获取监控文件夹 ID 失败: %w", err)
+	}
+	folder.ID = int(id)
+	folder.CreatedAt = now
+	return nil
+}
+
+// UpdateWatchFolder 更新监控文件夹设置
+func (r *SQLRepository) UpdateWatchFolder(folder *models.WatchFolder) error {
+	res, err := r.db.Exec(`UPDATE watch_folders SET dataset_id = ?, path = ?, extensions = ?, mode = ?, enabled = ?, last_scan = ? WHERE id = ?`,
+		folder.DatasetID, folder.Path, folder.Extensions, folder.Mode, folder.Enabled, nullTime(folder.LastScan), folder.ID)
+	if err != nil {
+		return fmt.Errorf("更新监控文件夹失败: %w", err)
+	}
+	return checkAffected(res)
+}
+
+// DeleteWatchFolder 删除监控文件夹，不删除其中的文件
+func (r *SQLRepository) DeleteWatchFolder(id int) error {
+	res, err := r.db.Exec(`DELETE FROM watch_folders WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("删除监控文件夹失败: %w", err)
+	}
+	return checkAffected(res)
+}
+
+// nullTime 零值时间写入 NULL
+func nullTime(t time.Time) sql.NullTime {
+	return sql.NullTime{Time: t, Valid: !t.IsZero()}
+}
@@ -0,0 +1,230 @@
+package ingest
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"dataset-sync/models"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	DefaultArchiveMaxSize = 2 << 30 // 默认压缩包解压后总大小上限 2GB
+	maxCompressionRatio   = 200     // 单个文件允许的最大压缩比，超过视为压缩炸弹
+	maxArchiveEntries     = 200000  // 压缩包内最多处理的文件数
+)
+
+var ErrArchiveTooLarge = errors.New("压缩包解压后超过大小上限")
+
+// IsArchive 判断文件是否为支持的压缩包（.zip、.tar、.tar.gz、.tgz）
+func IsArchive(name string) bool {
+	name = strings.ToLower(name)
+	for _, ext := range []string{".zip", ".tar", ".tar.gz", ".tgz"} {
+		if strings.HasSuffix(name, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// extractor 解压状态，统计已写出的字节数
+type extractor struct {
+	dir     string
+	maxSize int64
+	written int64
+	result  *ScanResult
+}
+
+// ExtractArchive 将压缩包中的图片流式解压到 dir，返回可直接导入的扫描结果
+// 会拒绝路径穿越和链接文件，同名条目追加序号保存，并在解压总大小超过 maxSize 或压缩比异常时中止
+func ExtractArchive(archivePath, dir string, maxSize int64) (*ScanResult, error) {
+	if maxSize <= 0 {
+		maxSize = DefaultArchiveMaxSize
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("创建解压目录失败: %w", err)
+	}
+	ex := &extractor{
+		dir:     dir,
+		maxSize: maxSize,
+		result:  &ScanResult{Root: dir, Source: models.SourceArchive, Archive: archivePath},
+	}
+
+	var err error
+	name := strings.ToLower(archivePath)
+	switch {
+	case strings.HasSuffix(name, ".zip"):
+		err = ex.extractZip(archivePath)
+	case strings.HasSuffix(name, ".tar.gz"), strings.HasSuffix(name, ".tgz"):
+		err = ex.extractTar(archivePath, true)
+	case strings.HasSuffix(name, ".tar"):
+		err = ex.extractTar(archivePath, false)
+	default:
+		err = fmt.Errorf("不支持的压缩包格式: %s", filepath.Base(archivePath))
+	}
+	if err != nil {
+		os.RemoveAll(dir)
+		return nil, err
+	}
+	return ex.result, nil
+}
+
+// extractZip 解压 zip 文件
+func (ex *extractor) extractZip(archivePath string) error {
+	r, err := zip.OpenReader(archivePath)
+	if err != nil {
+		return fmt.Errorf("打开压缩包失败: %w", err)
+	}
+	defer r.Close()
+
+	if len(r.File) > maxArchiveEntries {
+		return fmt.Errorf("压缩包文件数量 %d 超过上限 %d", len(r.File), maxArchiveEntries)
+	}
+	// 先按声明的大小检查一次，明显超限的直接拒绝
+	var declared uint64
+	for _, f := range r.File {
+		declared += f.UncompressedSize64
+	}
+	if declared > uint64(ex.maxSize) {
+		return ErrArchiveTooLarge
+	}
+
+	for _, f := range r.File {
+		if !f.Mode().IsRegular() {
+			continue // 跳过目录与链接
+		}
+		if f.CompressedSize64 > 0 && f.UncompressedSize64/f.CompressedSize64 > maxCompressionRatio {
+			return fmt.Errorf("文件 %s 压缩比异常，疑似压缩炸弹", f.Name)
+		}
+		if err := ex.extractEntry(f.Name, func() (io.ReadCloser, error) { return f.Open() }); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// extractTar 解压 tar / tar.gz 文件
+func (ex *extractor) extractTar(archivePath string, gzipped bool) error {
+	f, err := os.Open(archivePath)
+	if err != nil {
+		return fmt.Errorf("打开压缩包失败: %w", err)
+	}
+	defer f.Close()
+
+	var reader io.Reader = f
+	if gzipped {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return fmt.Errorf("打开压缩包失败: %w", err)
+		}
+		defer gz.Close()
+		reader = gz
+	}
+
+	tr := tar.NewReader(reader)
+	for entries := 0; ; entries++ {
+		if entries > maxArchiveEntries {
+			return fmt.Errorf("压缩包文件数量超过上限 %d", maxArchiveEntries)
+		}
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("读取压缩包失败: %w", err)
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue // 跳过目录、链接和设备文件
+		}
+		if header.Size > ex.maxSize-ex.written {
+			return ErrArchiveTooLarge
+		}
+		if err := ex.extractEntry(header.Name, func() (io.ReadCloser, error) { return io.NopCloser(tr), nil }); err != nil {
+			return err
+		}
+	}
+}
+
+// extractEntry 校验条目路径后写出图片文件，非图片条目只计数
+func (ex *extractor) extractEntry(name string, open func() (io.ReadCloser, error)) error {
+	rel, err := safeEntryPath(name)
+	if err != nil {
+		return err
+	}
+	if strings.HasPrefix(path.Base(rel), ".") || strings.Contains(rel, "__MACOSX/") {
+		return nil // 跳过隐藏文件和 macOS 元数据
+	}
+	if !IsSupported(rel) {
+		ex.result.Skipped++
+		return nil
+	}
+
+	target := filepath.Join(ex.dir, filepath.FromSlash(rel))
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return fmt.Errorf("创建解压目录失败: %w", err)
+	}
+	src, err := open()
+	if err != nil {
+		return fmt.Errorf("读取压缩包条目 %s 失败: %w", name, err)
+	}
+	defer src.Close()
+
+	out, target, err := createEntryFile(target)
+	if err != nil {
+		return fmt.Errorf("写出文件 %s 失败: %w", rel, err)
+	}
+	if renamed := filepath.Base(target); renamed != path.Base(rel) {
+		fmt.Printf("压缩包中有同名条目 %s，另存为 %s\n", rel, renamed)
+		rel = path.Join(path.Dir(rel), renamed)
+	}
+	// 不信任条目声明的大小，按剩余额度限制实际写出的字节数
+	remaining := ex.maxSize - ex.written
+	n, err := io.Copy(out, io.LimitReader(src, remaining+1))
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	ex.written += n
+	if err != nil {
+		return fmt.Errorf("解压文件 %s 失败: %w", rel, err)
+	}
+	if n > remaining {
+		return ErrArchiveTooLarge
+	}
+
+	ex.result.Files = append(ex.result.Files, ScanFile{Path: target, RelPath: filepath.FromSlash(rel), Size: n})
+	ex.result.TotalSize += n
+	return nil
+}
+
+// createEntryFile 以独占方式创建解压出的文件，压缩包中有同名条目时追加 _1、_2 ...，返回实际使用的路径
+func createEntryFile(target string) (*os.File, string, error) {
+	ext := filepath.Ext(target)
+	base := strings.TrimSuffix(target, ext)
+	candidate := target
+	for i := 1; ; i++ {
+		out, err := os.OpenFile(candidate, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+		if !errors.Is(err, os.ErrExist) {
+			return out, candidate, err
+		}
+		candidate = fmt.Sprintf("%s_%d%s", base, i, ext)
+	}
+}
+
+// safeEntryPath 清理压缩包条目路径，拒绝绝对路径和 .. 路径穿越
+func safeEntryPath(name string) (string, error) {
+	name = strings.ReplaceAll(name, "\\", "/")
+	if strings.HasPrefix(name, "/") || filepath.IsAbs(name) || filepath.VolumeName(name) != "" {
+		return "", fmt.Errorf("压缩包条目使用绝对路径: %s", name)
+	}
+	cleaned := path.Clean(name)
+	if cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+		return "", fmt.Errorf("压缩包条目路径非法: %s", name)
+	}
+	return cleaned, nil
+}
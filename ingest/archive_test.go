@@ -0,0 +1,194 @@
+package ingest
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// archiveEntry 测试压缩包中的一个条目
+type archiveEntry struct {
+	name string
+	data string
+}
+
+// writeZip 生成 zip 文件，条目不压缩，method 非零时使用指定的压缩方式
+func writeZip(t *testing.T, entries []archiveEntry, method uint16) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "test.zip")
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, entry := range entries {
+		w, err := zw.CreateHeader(&zip.FileHeader{Name: entry.name, Method: method})
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(entry.data))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// writeTar 生成 tar.gz 文件
+func writeTar(t *testing.T, entries []archiveEntry) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "test.tar.gz")
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for _, entry := range entries {
+		header := &tar.Header{Name: entry.name, Mode: 0o644, Size: int64(len(entry.data)), Typeflag: tar.TypeReg}
+		if err := tw.WriteHeader(header); err != nil {
+			t.Fatal(err)
+		}
+		tw.Write([]byte(entry.data))
+	}
+	tw.Close()
+	gz.Close()
+	if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// extractedFiles 解压结果中的相对路径及内容
+func extractedFiles(t *testing.T, result *ScanResult) map[string]string {
+	t.Helper()
+	files := map[string]string{}
+	for _, file := range result.Files {
+		data, err := os.ReadFile(file.Path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		files[filepath.ToSlash(file.RelPath)] = string(data)
+	}
+	return files
+}
+
+// archiveWriters 同一组条目分别打包为 zip 和 tar.gz
+var archiveWriters = map[string]func(t *testing.T, entries []archiveEntry) string{
+	"zip": func(t *testing.T, entries []archiveEntry) string { return writeZip(t, entries, zip.Store) },
+	"tar": writeTar,
+}
+
+func TestExtractArchive(t *testing.T) {
+	entries := []archiveEntry{
+		{"cats/a.png", "a"},
+		{"cats/readme.txt", "not an image"},
+		{".hidden.png", "hidden"},
+		{"__MACOSX/cats/._a.png", "metadata"},
+		{"b.jpg", "b"},
+	}
+	for name, write := range archiveWriters {
+		t.Run(name, func(t *testing.T) {
+			result, err := ExtractArchive(write(t, entries), filepath.Join(t.TempDir(), "out"), 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			files := extractedFiles(t, result)
+			if len(files) != 2 || files["cats/a.png"] != "a" || files["b.jpg"] != "b" {
+				t.Fatalf("files = %v, want only the two images", files)
+			}
+			if result.Skipped != 1 || result.TotalSize != 2 {
+				t.Fatalf("skipped=%d total=%d, want 1 and 2", result.Skipped, result.TotalSize)
+			}
+		})
+	}
+}
+
+func TestExtractArchiveRejectsTraversal(t *testing.T) {
+	for _, entry := range []string{"../evil.png", "cats/../../evil.png", `..\evil.png`, "/etc/evil.png"} {
+		for name, write := range archiveWriters {
+			t.Run(name+" "+entry, func(t *testing.T) {
+				dir := filepath.Join(t.TempDir(), "out")
+				archive := write(t, []archiveEntry{{"ok.png", "ok"}, {entry, "evil"}})
+				if _, err := ExtractArchive(archive, dir, 0); err == nil {
+					t.Fatal("want an error for an entry outside the target directory")
+				}
+				if _, err := os.Stat(dir); !errors.Is(err, os.ErrNotExist) {
+					t.Fatal("target directory was not removed after the error")
+				}
+				if _, err := os.Stat(filepath.Join(filepath.Dir(dir), "evil.png")); !errors.Is(err, os.ErrNotExist) {
+					t.Fatal("entry was written outside the target directory")
+				}
+			})
+		}
+	}
+}
+
+func TestExtractArchiveRejectsOversized(t *testing.T) {
+	tests := []struct {
+		name    string
+		archive func(t *testing.T) string
+		maxSize int64
+		want    string
+	}{
+		{"zip total size", func(t *testing.T) string {
+			return writeZip(t, []archiveEntry{{"a.png", strings.Repeat("a", 60)}, {"b.png", strings.Repeat("b", 60)}}, zip.Store)
+		}, 100, ErrArchiveTooLarge.Error()},
+		{"tar total size", func(t *testing.T) string {
+			return writeTar(t, []archiveEntry{{"a.png", strings.Repeat("a", 60)}, {"b.png", strings.Repeat("b", 60)}})
+		}, 100, ErrArchiveTooLarge.Error()},
+		{"compression ratio", func(t *testing.T) string {
+			return writeZip(t, []archiveEntry{{"a.png", strings.Repeat("\x00", 1<<20)}}, zip.Deflate)
+		}, 0, "压缩比异常"},
+		{"entry count", func(t *testing.T) string {
+			entries := make([]archiveEntry, maxArchiveEntries+1)
+			for i := range entries {
+				entries[i].name = "d/"
+			}
+			return writeZip(t, entries, zip.Store)
+		}, 0, "超过上限"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := filepath.Join(t.TempDir(), "out")
+			_, err := ExtractArchive(tt.archive(t), dir, tt.maxSize)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("err = %v, want %q", err, tt.want)
+			}
+			if _, err := os.Stat(dir); !errors.Is(err, os.ErrNotExist) {
+				t.Fatal("target directory was not removed after the error")
+			}
+		})
+	}
+}
+
+func TestExtractArchiveKeepsDuplicateNames(t *testing.T) {
+	entries := []archiveEntry{
+		{"cats/a.png", "first"},
+		{"cats/a.png", "second"},
+		{"./cats/a.png", "third"},
+		{"cats/a_1.png", "fourth"},
+	}
+	for name, write := range archiveWriters {
+		t.Run(name, func(t *testing.T) {
+			result, err := ExtractArchive(write(t, entries), filepath.Join(t.TempDir(), "out"), 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			files := extractedFiles(t, result)
+			var got []string
+			for rel, data := range files {
+				got = append(got, rel+"="+data)
+			}
+			sort.Strings(got)
+			want := []string{"cats/a.png=first", "cats/a_1.png=second", "cats/a_1_1.png=fourth", "cats/a_2.png=third"}
+			if strings.Join(got, ",") != strings.Join(want, ",") {
+				t.Fatalf("files = %v, want %v", got, want)
+			}
+		})
+	}
+}
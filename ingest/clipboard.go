@@ -0,0 +1,77 @@
+package ingest
+
+import (
+	"bytes"
+	"dataset-sync/models"
+	"fmt"
+	"image"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ClipboardOrigin 剪贴板图片在上传记录中的原始路径
+const ClipboardOrigin = "剪贴板"
+
+// ClipboardItem 将剪贴板中的图片数据写入缓存目录 clipboard 子目录，返回对应的导入项
+// 每张图片放在单独的临时目录（TempRoot）中，由调用方在导入结束后删除
+// 剪贴板图片没有原始文件名，入库时总是按自动重命名模板生成文件名
+func (in *Ingester) ClipboardItem(data []byte) (Item, error) {
+	_, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return Item{}, fmt.Errorf("剪贴板中的数据不是支持的图片: %w", err)
+	}
+	dir := filepath.Join(in.tmpDir(), "clipboard", randomName())
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return Item{}, fmt.Errorf("创建临时目录失败: %w", err)
+	}
+	name := "clipboard_" + time.Now().Format("20060102_150405") + formatExt(format)
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		os.RemoveAll(dir)
+		return Item{}, fmt.Errorf("保存剪贴板图片失败: %w", err)
+	}
+	return Item{Path: path, Name: name, Origin: ClipboardOrigin, Source: models.SourceClipboard, TempRoot: dir}, nil
+}
+
+// ParseClipboardText 从剪贴板文本中取出本地路径（包括 file:// 链接）和图片链接，每行一个
+// 不是链接、也不存在的本地路径的行忽略
+func ParseClipboardText(text string) (paths, urls []string) {
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.Trim(strings.TrimSpace(line), `"'`)
+		if line == "" || strings.HasPrefix(line, "#") { // text/uri-list 中的注释行
+			continue
+		}
+		lower := strings.ToLower(line)
+		switch {
+		case strings.HasPrefix(lower, "http://") || strings.HasPrefix(lower, "https://"):
+			urls = append(urls, line)
+		case strings.HasPrefix(lower, "file://"):
+			if u, err := url.Parse(line); err == nil && u.Path != "" {
+				paths = append(paths, fileURLPath(u))
+			}
+		default:
+			if _, err := os.Stat(line); err == nil {
+				paths = append(paths, line)
+			}
+		}
+	}
+	return paths, urls
+}
+
+// fileURLPath file:// 链接对应的本地路径
+// file:///C:/a.jpg 去掉盘符前的 /；file://host/share/a.jpg 为 UNC 路径 \\host\share\a.jpg
+func fileURLPath(u *url.URL) string {
+	p := u.Path
+	if len(p) >= 3 && p[0] == '/' && p[2] == ':' {
+		if drive := p[1]; 'a' <= drive && drive <= 'z' || 'A' <= drive && drive <= 'Z' {
+			p = p[1:]
+		}
+	}
+	if u.Host != "" && !strings.EqualFold(u.Host, "localhost") {
+		p = "//" + u.Host + p
+	}
+	return filepath.FromSlash(p)
+}
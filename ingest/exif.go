@@ -0,0 +1,148 @@
+package ingest
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/draw"
+	"io"
+	"os"
+)
+
+// EXIF 方向取值 1-8，1 表示无需旋转
+const (
+	orientationNormal      = 1
+	orientationTag         = 0x0112
+	maxExifSegmentsScanned = 32 // 最多检查的 JPEG 段数，方向信息总在文件开头
+)
+
+// readOrientation 读取 JPEG / TIFF 文件中的 EXIF 方向，读取失败或没有方向信息时返回 1
+func readOrientation(path, format string) int {
+	f, err := os.Open(path)
+	if err != nil {
+		return orientationNormal
+	}
+	defer f.Close()
+
+	switch format {
+	case "jpeg":
+		return jpegOrientation(bufio.NewReader(f))
+	case "tiff":
+		// TIFF 文件本身就是 EXIF 使用的结构，只需读取文件头和第一个 IFD
+		header := make([]byte, 64*1024)
+		n, _ := io.ReadFull(f, header)
+		return tiffOrientation(header[:n])
+	}
+	return orientationNormal
+}
+
+// jpegOrientation 逐段查找 APP1 中的 Exif 数据
+func jpegOrientation(r *bufio.Reader) int {
+	var soi [2]byte
+	if _, err := io.ReadFull(r, soi[:]); err != nil || soi[0] != 0xFF || soi[1] != 0xD8 {
+		return orientationNormal
+	}
+	for i := 0; i < maxExifSegmentsScanned; i++ {
+		var marker [4]byte
+		if _, err := io.ReadFull(r, marker[:]); err != nil || marker[0] != 0xFF {
+			return orientationNormal
+		}
+		// SOS 之后是图像数据，不会再有 Exif
+		if marker[1] == 0xDA || marker[1] == 0xD9 {
+			return orientationNormal
+		}
+		length := int(binary.BigEndian.Uint16(marker[2:])) - 2
+		if length < 0 {
+			return orientationNormal
+		}
+		segment := make([]byte, length)
+		if _, err := io.ReadFull(r, segment); err != nil {
+			return orientationNormal
+		}
+		if marker[1] == 0xE1 && bytes.HasPrefix(segment, []byte("Exif\x00\x00")) {
+			return tiffOrientation(segment[6:])
+		}
+	}
+	return orientationNormal
+}
+
+// tiffOrientation 从 TIFF 结构的第一个 IFD 中读取方向标签
+func tiffOrientation(data []byte) int {
+	if len(data) < 8 {
+		return orientationNormal
+	}
+	var order binary.ByteOrder
+	switch string(data[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return orientationNormal
+	}
+	if order.Uint16(data[2:]) != 42 {
+		return orientationNormal
+	}
+	offset := int(order.Uint32(data[4:]))
+	if offset < 8 || offset+2 > len(data) {
+		return orientationNormal
+	}
+	count := int(order.Uint16(data[offset:]))
+	for i := 0; i < count; i++ {
+		entry := offset + 2 + i*12
+		if entry+12 > len(data) {
+			break
+		}
+		if order.Uint16(data[entry:]) == orientationTag {
+			// 类型为 SHORT，值直接存放在值字段的前两个字节
+			if value := int(order.Uint16(data[entry+8:])); value >= 1 && value <= 8 {
+				return value
+			}
+			break
+		}
+	}
+	return orientationNormal
+}
+
+// applyOrientation 按 EXIF 方向旋转或翻转图片，使其按正常方向显示
+func applyOrientation(img image.Image, orientation int) image.Image {
+	if orientation <= orientationNormal || orientation > 8 {
+		return img
+	}
+	bounds := img.Bounds()
+	src := image.NewNRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(src, src.Bounds(), img, bounds.Min, draw.Src)
+	w, h := bounds.Dx(), bounds.Dy()
+
+	// 5-8 需要交换宽高
+	dw, dh := w, h
+	if orientation >= 5 {
+		dw, dh = h, w
+	}
+	dst := image.NewNRGBA(image.Rect(0, 0, dw, dh))
+	for dy := 0; dy < dh; dy++ {
+		for dx := 0; dx < dw; dx++ {
+			var sx, sy int
+			switch orientation {
+			case 2: // 水平翻转
+				sx, sy = w-1-dx, dy
+			case 3: // 旋转 180°
+				sx, sy = w-1-dx, h-1-dy
+			case 4: // 垂直翻转
+				sx, sy = dx, h-1-dy
+			case 5: // 沿左上-右下对角线翻转
+				sx, sy = dy, dx
+			case 6: // 顺时针旋转 90°
+				sx, sy = dy, h-1-dx
+			case 7: // 沿右上-左下对角线翻转
+				sx, sy = w-1-dy, h-1-dx
+			case 8: // 逆时针旋转 90°
+				sx, sy = w-1-dy, dx
+			}
+			si, di := src.PixOffset(sx, sy), dst.PixOffset(dx, dy)
+			copy(dst.Pix[di:di+4], src.Pix[si:si+4])
+		}
+	}
+	return dst
+}
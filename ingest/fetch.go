@@ -0,0 +1,192 @@
+package ingest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultDownloadMaxSize = 20 << 20 // 默认单张图片下载上限 20MB
+	DefaultDownloadRetries = 3        // 默认重试次数
+)
+
+// ProgressFunc 下载进度回调，total 未知时为 -1
+type ProgressFunc func(done, total int64)
+
+// Fetcher 通过 HTTP(S) 下载图片到缓存目录
+// Client 可以替换，方便在测试中指向 httptest 服务
+type Fetcher struct {
+	Client     *http.Client
+	MaxBytes   int64         // 单张图片大小上限
+	Retries    int           // 失败后的重试次数
+	RetryDelay time.Duration // 首次重试等待时间，之后按倍数增长
+	Dir        string        // 下载目录
+}
+
+// permanentError 不需要重试的错误（如 404、类型不符、超出大小）
+type permanentError struct{ err error }
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// NewFetcher 创建下载器
+func NewFetcher(dir string, maxBytes int64, retries int) *Fetcher {
+	if maxBytes <= 0 {
+		maxBytes = DefaultDownloadMaxSize
+	}
+	if retries <= 0 {
+		retries = DefaultDownloadRetries
+	}
+	return &Fetcher{
+		Client:     &http.Client{Timeout: 2 * time.Minute},
+		MaxBytes:   maxBytes,
+		Retries:    retries,
+		RetryDelay: time.Second,
+		Dir:        dir,
+	}
+}
+
+// Fetch 下载图片并确认可以解码，返回下载后的本地文件路径
+// 网络错误和 5xx/429 会按指数退避重试
+func (f *Fetcher) Fetch(ctx context.Context, rawURL string, progress ProgressFunc) (string, error) {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil {
+		return "", fmt.Errorf("链接格式错误: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return "", fmt.Errorf("不支持的链接协议: %s", u.Scheme)
+	}
+
+	delay := f.RetryDelay
+	for attempt := 0; ; attempt++ {
+		file, err := f.download(ctx, u, progress)
+		if err == nil {
+			return file, nil
+		}
+		var permanent *permanentError
+		if errors.As(err, &permanent) || attempt >= f.Retries || ctx.Err() != nil {
+			return "", err
+		}
+		fmt.Printf("下载失败，%v 后重试(%d/%d): %v\n", delay, attempt+1, f.Retries, err)
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
+
+// download 执行一次下载
+func (f *Fetcher) download(ctx context.Context, u *url.URL, progress ProgressFunc) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return "", &permanentError{err}
+	}
+	req.Header.Set("Accept", "image/*")
+
+	resp, err := f.Client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("请求失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return "", fmt.Errorf("服务器返回 %s", resp.Status)
+	case resp.StatusCode != http.StatusOK:
+		return "", &permanentError{fmt.Errorf("服务器返回 %s", resp.Status)}
+	}
+
+	// 检查类型与大小
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if !strings.HasPrefix(mediaType, "image/") {
+		return "", &permanentError{fmt.Errorf("链接内容不是图片: %s", resp.Header.Get("Content-Type"))}
+	}
+	if resp.ContentLength > f.MaxBytes {
+		return "", &permanentError{fmt.Errorf("图片大小 %d 字节超过上限 %d 字节", resp.ContentLength, f.MaxBytes)}
+	}
+
+	if err := os.MkdirAll(f.Dir, 0o755); err != nil {
+		return "", &permanentError{fmt.Errorf("创建下载目录失败: %w", err)}
+	}
+	file := filepath.Join(f.Dir, randomName()+"_"+downloadName(u, mediaType))
+	out, err := os.Create(file)
+	if err != nil {
+		return "", &permanentError{fmt.Errorf("创建下载文件失败: %w", err)}
+	}
+
+	// 多读一个字节用于判断是否超过上限
+	body := io.LimitReader(resp.Body, f.MaxBytes+1)
+	written, err := io.Copy(out, &progressReader{r: body, total: resp.ContentLength, progress: progress})
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(file)
+		return "", fmt.Errorf("下载中断: %w", err)
+	}
+	if written > f.MaxBytes {
+		os.Remove(file)
+		return "", &permanentError{fmt.Errorf("图片大小超过上限 %d 字节", f.MaxBytes)}
+	}
+
+	// 完整解码一次确认图片有效
+	if _, err := decodeImage(file); err != nil {
+		os.Remove(file)
+		return "", &permanentError{err}
+	}
+	return file, nil
+}
+
+// downloadName 根据链接路径生成文件名，缺少扩展名时按 Content-Type 补全
+func downloadName(u *url.URL, mediaType string) string {
+	name := safeName(path.Base(u.Path))
+	if name == "_" || name == "." || name == "/" {
+		name = "download"
+	}
+	if path.Ext(name) == "" {
+		for format, mimeType := range formatMIMETypes {
+			if mimeType == mediaType {
+				name += formatExt(format)
+				break
+			}
+		}
+	}
+	return name
+}
+
+// formatExt 图片格式对应的常用扩展名
+func formatExt(format string) string {
+	if format == "jpeg" {
+		return ".jpg"
+	}
+	return "." + format
+}
+
+// progressReader 读取时回调进度
+type progressReader struct {
+	r        io.Reader
+	done     int64
+	total    int64
+	progress ProgressFunc
+}
+
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	p.done += int64(n)
+	if p.progress != nil && n > 0 {
+		p.progress(p.done, p.total)
+	}
+	return n, err
+}
@@ -0,0 +1,215 @@
+package ingest
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// testPNG 一张可以解码的小图片
+func testPNG(t *testing.T) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 4, 4))); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// newTestFetcher 下载到临时目录、重试间隔很短的下载器，handler 为假的图片服务器
+func newTestFetcher(t *testing.T, handler http.HandlerFunc) (*Fetcher, string, *atomic.Int32) {
+	t.Helper()
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		handler(w, r)
+	}))
+	t.Cleanup(server.Close)
+
+	f := NewFetcher(t.TempDir(), 1<<10, 3)
+	f.Client = server.Client()
+	f.RetryDelay = time.Millisecond
+	return f, server.URL, &requests
+}
+
+// assertNoDownloads 失败的下载不应在下载目录中留下文件
+func assertNoDownloads(t *testing.T, dir string) {
+	t.Helper()
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 0 {
+		t.Fatalf("download dir has %d leftover files", len(entries))
+	}
+}
+
+func TestFetchDownloadsImage(t *testing.T) {
+	data := testPNG(t)
+	f, url, _ := newTestFetcher(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.Write(data)
+	})
+
+	var done, total int64
+	file, err := f.Fetch(context.Background(), url+"/images/cat", func(d, n int64) { done, total = d, n })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(filepath.Base(file), "_cat.png") {
+		t.Fatalf("file = %s, want the name completed with .png", file)
+	}
+	if got, _ := os.ReadFile(file); !bytes.Equal(got, data) {
+		t.Fatal("downloaded content differs")
+	}
+	if done != int64(len(data)) || total != int64(len(data)) {
+		t.Fatalf("progress = %d/%d, want %d/%d", done, total, len(data), len(data))
+	}
+}
+
+func TestFetchRejectsNonImage(t *testing.T) {
+	f, url, requests := newTestFetcher(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte("<html></html>"))
+	})
+
+	_, err := f.Fetch(context.Background(), url+"/page", nil)
+	var permanent *permanentError
+	if !errors.As(err, &permanent) || !strings.Contains(err.Error(), "不是图片") {
+		t.Fatalf("err = %v, want a permanent non-image error", err)
+	}
+	if requests.Load() != 1 {
+		t.Fatalf("sent %d requests, want no retries", requests.Load())
+	}
+	assertNoDownloads(t, f.Dir)
+}
+
+func TestFetchRejectsOversizedImage(t *testing.T) {
+	large := bytes.Repeat([]byte{0}, 2<<10)
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+	}{
+		{"content length", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "image/png")
+			w.Write(large)
+		}},
+		{"chunked", func(w http.ResponseWriter, r *http.Request) {
+			// 不声明大小，只能在下载时发现超出上限
+			w.Header().Set("Content-Type", "image/png")
+			w.(http.Flusher).Flush()
+			w.Write(large)
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, url, requests := newTestFetcher(t, tt.handler)
+			_, err := f.Fetch(context.Background(), url+"/large.png", nil)
+			if err == nil || !strings.Contains(err.Error(), "超过上限") {
+				t.Fatalf("err = %v, want a size limit error", err)
+			}
+			if requests.Load() != 1 {
+				t.Fatalf("sent %d requests, want no retries", requests.Load())
+			}
+			assertNoDownloads(t, f.Dir)
+		})
+	}
+}
+
+func TestFetchRetriesServerErrors(t *testing.T) {
+	data := testPNG(t)
+	statuses := []int{http.StatusServiceUnavailable, http.StatusTooManyRequests}
+	var calls atomic.Int32
+	f, url, requests := newTestFetcher(t, func(w http.ResponseWriter, r *http.Request) {
+		if i := int(calls.Add(1)) - 1; i < len(statuses) {
+			w.WriteHeader(statuses[i])
+			return
+		}
+		w.Header().Set("Content-Type", "image/png")
+		w.Write(data)
+	})
+
+	file, err := f.Fetch(context.Background(), url+"/a.png", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if requests.Load() != 3 {
+		t.Fatalf("sent %d requests, want 3", requests.Load())
+	}
+	if _, err := os.Stat(file); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFetchGivesUpAfterRetries(t *testing.T) {
+	f, url, requests := newTestFetcher(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	})
+
+	if _, err := f.Fetch(context.Background(), url+"/a.png", nil); err == nil {
+		t.Fatal("want an error after all retries fail")
+	}
+	if want := int32(f.Retries + 1); requests.Load() != want {
+		t.Fatalf("sent %d requests, want %d", requests.Load(), want)
+	}
+}
+
+func TestFetchDoesNotRetryNotFound(t *testing.T) {
+	f, url, requests := newTestFetcher(t, http.NotFound)
+
+	_, err := f.Fetch(context.Background(), url+"/a.png", nil)
+	var permanent *permanentError
+	if !errors.As(err, &permanent) {
+		t.Fatalf("err = %v, want a permanent error", err)
+	}
+	if requests.Load() != 1 {
+		t.Fatalf("sent %d requests, want no retries", requests.Load())
+	}
+}
+
+func TestFetchRejectsUndecodableImage(t *testing.T) {
+	// 声明为 PNG，文件头也是 PNG，但内容被截断
+	data := testPNG(t)[:20]
+	f, url, requests := newTestFetcher(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.Write(data)
+	})
+
+	_, err := f.Fetch(context.Background(), url+"/broken.png", nil)
+	if err == nil || !IsRejected(err) {
+		t.Fatalf("err = %v, want a decode error", err)
+	}
+	if requests.Load() != 1 {
+		t.Fatalf("sent %d requests, want no retries", requests.Load())
+	}
+	assertNoDownloads(t, f.Dir)
+}
+
+func TestFetchRejectsHugeImage(t *testing.T) {
+	// GIF 头信息声明 20000x20000，超过像素上限，不应尝试完整解码
+	header := []byte("GIF89a\x20\x4e\x20\x4e\x00\x00\x00")
+	f, url, _ := newTestFetcher(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/gif")
+		w.Write(header)
+	})
+
+	_, err := f.Fetch(context.Background(), url+"/huge.gif", nil)
+	if !IsRejected(err) || !strings.Contains(err.Error(), "像素上限") {
+		t.Fatalf("err = %v, want a pixel limit error", err)
+	}
+	assertNoDownloads(t, f.Dir)
+}
+
+func TestFetchRejectsUnsupportedScheme(t *testing.T) {
+	f := NewFetcher(t.TempDir(), 0, 0)
+	if _, err := f.Fetch(context.Background(), "ftp://example.com/a.png", nil); err == nil {
+		t.Fatal("want an error for ftp links")
+	}
+}
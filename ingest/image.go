@@ -0,0 +1,117 @@
+package ingest
+
+import (
+	"crypto/sha256"
+	"dataset-sync/models"
+	"dataset-sync/phash"
+	"encoding/hex"
+	"fmt"
+	"image"
+	"io"
+	"os"
+	"path/filepath"
+
+	// 注册支持的图片解码器
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+
+	_ "golang.org/x/image/bmp"
+	_ "golang.org/x/image/tiff"
+	_ "golang.org/x/image/webp"
+)
+
+// DefaultMaxPixels 完整解码前允许的最大像素数（1 亿像素，解码后约占 400MB 内存）
+// 与入库规则无关，总是检查，防止头信息声明超大尺寸的图片耗尽内存
+const DefaultMaxPixels = 100_000_000
+
+// formatMIMETypes 解码格式对应的 MIME 类型
+var formatMIMETypes = map[string]string{
+	"jpeg": "image/jpeg",
+	"png":  "image/png",
+	"gif":  "image/gif",
+	"bmp":  "image/bmp",
+	"tiff": "image/tiff",
+	"webp": "image/webp",
+}
+
+// DescribeImage 读取图片文件，计算大小、SHA-256、像素尺寸和格式
+// 返回的记录尚未关联数据集，也没有写入数据库
+func DescribeImage(path string, source models.ImageSource) (*models.Image, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("打开图片失败: %w", err)
+	}
+	defer f.Close()
+
+	// 计算哈希与字节大小
+	hash := sha256.New()
+	size, err := io.Copy(hash, f)
+	if err != nil {
+		return nil, fmt.Errorf("读取图片失败: %w", err)
+	}
+
+	// 回到文件开头读取图片头信息
+	if _, err = f.Seek(0, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("读取图片失败: %w", err)
+	}
+	cfg, format, err := image.DecodeConfig(f)
+	if err != nil {
+		return nil, fmt.Errorf("无法识别的图片格式: %w", err)
+	}
+
+	return &models.Image{
+		FileName: filepath.Base(path),
+		Path:     path,
+		Size:     size,
+		SHA256:   hex.EncodeToString(hash.Sum(nil)),
+		Width:    cfg.Width,
+		Height:   cfg.Height,
+		Format:   format,
+		MIMEType: formatMIMETypes[format],
+		Source:   source,
+	}, nil
+}
+
+// decodeImage 完整解码图片，头信息正常但数据损坏或被截断的文件会在这里失败
+// 解码前先读取头信息，像素数超过 DefaultMaxPixels 时不解码
+func decodeImage(path string) (image.Image, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("打开图片失败: %w", err)
+	}
+	defer f.Close()
+
+	cfg, _, err := image.DecodeConfig(f)
+	if err != nil {
+		return nil, rejectf("图片已损坏或不完整: %v", err)
+	}
+	if pixels := int64(cfg.Width) * int64(cfg.Height); pixels > DefaultMaxPixels {
+		return nil, rejectf("图片尺寸 %dx%d 超过 %d 万像素上限", cfg.Width, cfg.Height, DefaultMaxPixels/10000)
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("读取图片失败: %w", err)
+	}
+	decoded, _, err := image.Decode(f)
+	if err != nil {
+		return nil, rejectf("图片已损坏或不完整: %v", err)
+	}
+	return decoded, nil
+}
+
+// setHashes 计算感知哈希并写入 img
+func setHashes(img *models.Image, decoded image.Image) {
+	hashes := phash.Compute(decoded)
+	img.AHash, img.DHash, img.PHash = hashes.AHash, hashes.DHash, hashes.PHash
+	img.Hashed = true
+}
+
+// HashImage 完整解码图片并计算感知哈希，写入 img 的 AHash/DHash/PHash
+func HashImage(img *models.Image) error {
+	decoded, err := decodeImage(img.Path)
+	if err != nil {
+		return err
+	}
+	setHashes(img, decoded)
+	return nil
+}
@@ -0,0 +1,764 @@
+package ingest
+
+import (
+	"context"
+	"crypto/rand"
+	"dataset-sync/conf"
+	"dataset-sync/database"
+	"dataset-sync/models"
+	"dataset-sync/thumbnail"
+	"dataset-sync/utils"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// DuplicatePolicy 导入时遇到内容重复图片的处理方式
+type DuplicatePolicy string
+
+const (
+	DuplicateSkip DuplicatePolicy = "skip" // 跳过，不入库
+	DuplicateLink DuplicatePolicy = "link" // 不复制文件，新记录指向已有文件
+	DuplicateCopy DuplicatePolicy = "copy" // 仍然保存一份副本
+)
+
+// Options 单次导入的选项
+type Options struct {
+	Duplicates DuplicatePolicy // 重复图片处理方式，默认跳过
+}
+
+const DefaultDatasetName = "默认数据集" // 未指定目标数据集时使用
+
+// Ingester 负责把图片文件导入数据集：
+// 先复制到缓存目录暂存并校验，再移动到存放目录下的数据集文件夹，最后写入图片与上传记录
+type Ingester struct {
+	repo      database.DatasetRepository
+	cfg       *conf.DatasetConfig
+	hashLocks *keyedMutex // 同内容图片的查重与入库串行执行，避免并发导入时重复入库
+}
+
+// Result 单个文件的导入结果
+type Result struct {
+	Source    string                // 源文件路径
+	Image     *models.Image         // 导入成功后的图片记录，重复被跳过时为空
+	Record    *models.UploadDetails // 上传记录
+	Duplicate bool                  // 是否与已有图片内容重复
+	Existing  string                // 内容重复时已有图片的存储路径
+	Err       error                 // 失败原因
+}
+
+// New 创建导入器
+func New(repo database.DatasetRepository, cfg *conf.DatasetConfig) *Ingester {
+	return &Ingester{repo: repo, cfg: cfg, hashLocks: newKeyedMutex()}
+}
+
+// EnsureDataset 按名称查找数据集，不存在时自动创建
+func (in *Ingester) EnsureDataset(name string) (*models.Dataset, error) {
+	datasets, err := in.repo.ListDatasets()
+	if err != nil {
+		return nil, err
+	}
+	for _, ds := range datasets {
+		if ds.Name == name {
+			return ds, nil
+		}
+	}
+	ds := &models.Dataset{Name: name}
+	if err := in.repo.CreateDataset(ds); err != nil {
+		return nil, err
+	}
+	return ds, nil
+}
+
+// Item 待导入的一个文件
+type Item struct {
+	Path   string             // 本地文件路径
+	Name   string             // 入库使用的文件名
+	SubDir string             // 数据集目录下的相对子目录，用于保留原有目录结构
+	Label  string             // 类别标签
+	Origin string             // 原始路径或链接，写入上传记录
+	Source models.ImageSource // 图片来源
+	Meta   Metadata           // 清单文件提供的出处、许可证和自由属性
+
+	TempRoot  string                // 压缩包解压出的临时目录，由调用方在导入结束后删除
+	journalID int64                 // 对应的导入任务日志 ID，未记录日志时为 0
+	history   *models.UploadDetails // 重试时复用的上传记录，为空时新增记录
+	recovered bool                  // 重启后从任务日志恢复的任务，上次可能已经入库
+}
+
+// FileItem 本地文件对应的导入项
+func FileItem(path string, source models.ImageSource) Item {
+	return Item{Path: path, Name: filepath.Base(path), Origin: path, Source: source}
+}
+
+// fetchItem 下载链接中的图片，返回对应的导入项，下载失败时 Path 为空
+func fetchItem(ctx context.Context, fetcher *Fetcher, rawURL string, progress ProgressFunc) (Item, error) {
+	item := Item{Name: urlFileName(rawURL), Origin: rawURL, Source: models.SourceURL}
+	file, err := fetcher.Fetch(ctx, rawURL, progress)
+	if err != nil {
+		return item, err
+	}
+	// 下载文件名为 <随机前缀>_<文件名>，已按 Content-Type 补全扩展名
+	_, item.Name, _ = strings.Cut(filepath.Base(file), "_")
+	item.Path = file
+	return item, nil
+}
+
+// finishBatch 一批文件导入结束：标记数据集变化并清理缩略图缓存
+func (in *Ingester) finishBatch(ds *models.Dataset, results []Result) {
+	in.markChanged(ds, results)
+	in.evictThumbnails()
+}
+
+// Fetcher 按当前配置创建下载器，下载到缓存目录的 downloads 子目录
+func (in *Ingester) Fetcher() *Fetcher {
+	var maxBytes int64
+	var retries int
+	if in.cfg != nil {
+		maxBytes, retries = in.cfg.DownloadMaxSize, in.cfg.DownloadRetries
+	}
+	return NewFetcher(filepath.Join(in.tmpDir(), "downloads"), maxBytes, retries)
+}
+
+// Thumbnails 按当前配置返回缓存目录 thumbnails 子目录下的缩略图缓存
+func (in *Ingester) Thumbnails() *thumbnail.Cache {
+	var maxBytes int64
+	if in.cfg != nil {
+		maxBytes = in.cfg.ThumbnailCacheSize
+	}
+	return thumbnail.New(filepath.Join(in.tmpDir(), "thumbnails"), maxBytes)
+}
+
+// generateThumbnails 入库后生成常用尺寸的缩略图，失败不影响导入
+func (in *Ingester) generateThumbnails(img *models.Image, decoded image.Image) {
+	cache := in.Thumbnails()
+	for _, size := range thumbnail.Sizes {
+		if _, err := os.Stat(cache.Path(img.SHA256, size)); err == nil {
+			continue // 内容相同的图片已生成过
+		}
+		if _, err := cache.Generate(decoded, img.SHA256, size); err != nil {
+			fmt.Println("生成缩略图失败:", err)
+		}
+	}
+}
+
+// evictThumbnails 缩略图缓存超过上限时淘汰最久未使用的文件
+func (in *Ingester) evictThumbnails() {
+	if removed, err := in.Thumbnails().Evict(); err != nil {
+		fmt.Println("清理缩略图缓存失败:", err)
+	} else if removed > 0 {
+		fmt.Printf("清理缩略图缓存 %d 个文件\n", removed)
+	}
+}
+
+// RebuildThumbnails 清空缩略图缓存并为全部图片重新生成，progress 回调已处理数和总数
+func (in *Ingester) RebuildThumbnails(progress func(done, total int)) (int, error) {
+	cache := in.Thumbnails()
+	if err := cache.Clear(); err != nil {
+		return 0, err
+	}
+	datasets, err := in.repo.ListDatasets()
+	if err != nil {
+		return 0, err
+	}
+	var images []*models.Image
+	for _, ds := range datasets {
+		list, err := in.repo.ListImages(ds.ID)
+		if err != nil {
+			return 0, err
+		}
+		images = append(images, list...)
+	}
+
+	generated := 0
+	seen := make(map[string]bool)
+	for i, img := range images {
+		if !seen[img.SHA256] {
+			seen[img.SHA256] = true
+			ok := true
+			for _, size := range thumbnail.Sizes {
+				if _, err := cache.Get(img.SHA256, img.Path, size); err != nil {
+					fmt.Printf("生成缩略图失败 %s: %v\n", img.Path, err)
+					ok = false
+					break
+				}
+			}
+			if ok {
+				generated++
+			}
+		}
+		if progress != nil {
+			progress(i+1, len(images))
+		}
+	}
+	in.evictThumbnails()
+	return generated, nil
+}
+
+// ExtractArchive 将压缩包解压到缓存目录的 archives 子目录，导入完成后调用方需删除 ScanResult.Root
+func (in *Ingester) ExtractArchive(archivePath string) (*ScanResult, error) {
+	var maxSize int64
+	if in.cfg != nil {
+		maxSize = in.cfg.ArchiveMaxSize
+	}
+	dir := filepath.Join(in.tmpDir(), "archives", randomName())
+	return ExtractArchive(archivePath, dir, maxSize)
+}
+
+// BackfillHashes 为尚未计算感知哈希的图片补算并保存，返回补算成功的数量
+// datasetID 为 0 时处理全部数据集；无法读取的图片跳过，不影响其他图片
+func (in *Ingester) BackfillHashes(datasetID int) (int, error) {
+	var datasets []*models.Dataset
+	if datasetID != 0 {
+		ds, err := in.repo.GetDataset(datasetID)
+		if err != nil {
+			return 0, err
+		}
+		datasets = []*models.Dataset{ds}
+	} else {
+		var err error
+		if datasets, err = in.repo.ListDatasets(); err != nil {
+			return 0, err
+		}
+	}
+
+	count := 0
+	for _, ds := range datasets {
+		images, err := in.repo.ListImages(ds.ID)
+		if err != nil {
+			return count, err
+		}
+		for _, img := range images {
+			if img.Hashed {
+				continue
+			}
+			if err := HashImage(img); err != nil {
+				fmt.Printf("计算感知哈希失败 %s: %v\n", img.Path, err)
+				continue
+			}
+			if err := in.repo.UpdateImageHashes(img); err != nil {
+				return count, err
+			}
+			count++
+		}
+	}
+	return count, nil
+}
+
+// RemoveImage 删除图片记录，没有其他记录引用该文件时一并删除文件
+func (in *Ingester) RemoveImage(img *models.Image) error {
+	if err := in.repo.DeleteImage(img.ID); err != nil {
+		return err
+	}
+	inUse, err := in.repo.ImagePathInUse(img.Path)
+	if err != nil {
+		return err
+	}
+	if !inUse {
+		if err := os.Remove(img.Path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("删除图片文件失败: %w", err)
+		}
+	}
+	return nil
+}
+
+// ingest 导入一个文件并写入上传记录，prevErr 非空表示导入前（如下载）已失败
+// report 回调导入进度（0-1），可以为空；ctx 取消时中止导入并记录为已取消
+func (in *Ingester) ingest(ctx context.Context, ds *models.Dataset, item Item, opts Options, prevErr error, report func(float64)) Result {
+	if report == nil {
+		report = func(float64) {}
+	}
+	if item.history != nil {
+		// 重试：原记录标记为导入中
+		item.history.UploadStatus = models.UploadRunning
+		if err := in.repo.UpdateUploadHistory(item.history); err != nil {
+			fmt.Println("更新上传记录失败:", err)
+		}
+	}
+	result := Result{Source: item.Origin, Err: prevErr}
+	var img *models.Image
+	if result.Err == nil {
+		var existing *models.Image
+		img, existing, result.Err = in.importFile(ctx, ds, item, opts, report)
+		if existing != nil {
+			result.Duplicate, result.Existing = true, existing.Path
+		}
+	}
+
+	record := &models.UploadDetails{
+		DatasetID:    ds.ID,
+		ImageName:    item.Name,
+		DatasetName:  ds.Name,
+		ImagePath:    item.Origin,
+		UploadStatus: models.UploadSucceeded,
+		Duplicate:    result.Duplicate,
+		Attempts:     1,
+		SourcePath:   retrySource(item),
+	}
+	if item.history != nil {
+		record.ID = item.history.ID
+		record.Attempts = item.history.Attempts + 1
+	}
+	switch {
+	case errors.Is(result.Err, context.Canceled):
+		record.UploadStatus = models.UploadCancelled
+		record.ErrorMessage = "用户取消"
+	case result.Err != nil:
+		record.UploadStatus = models.UploadFailed
+		record.ErrorMessage = result.Err.Error()
+		if info, statErr := os.Stat(item.Path); item.Path != "" && statErr == nil {
+			record.ImageSize = utils.FormatSize(info.Size())
+		}
+	case img == nil:
+		// 重复且按策略跳过
+		record.UploadStatus = models.UploadSkippedDuplicate
+		if info, statErr := os.Stat(item.Path); statErr == nil {
+			record.ImageSize = utils.FormatSize(info.Size())
+		}
+	default:
+		record.ImageName = img.FileName
+		record.ImagePath = img.Path
+		record.ImageSize = utils.FormatSize(img.Size)
+		result.Image = img
+	}
+
+	if item.history != nil {
+		if err := in.repo.UpdateUploadHistory(record); err != nil {
+			fmt.Println("更新上传记录失败:", err)
+		}
+	} else if err := in.repo.AddUploadHistory(record); err != nil {
+		fmt.Println("写入上传记录失败:", err)
+	}
+	result.Record = record
+	return result
+}
+
+// retrySource 重试时使用的源：链接或本地文件路径，压缩包解压出的临时文件导入后即删除，无法重试
+func retrySource(item Item) string {
+	switch item.Source {
+	case models.SourceURL:
+		return item.Origin
+	case models.SourceArchive, models.SourceClipboard:
+		return "" // 临时文件在导入结束后删除，无法重试
+	}
+	return item.Path
+}
+
+// markChanged 数据集内容有变化，标记为未同步
+// 没有封面的数据集使用第一张导入成功的图片作为封面；ds 是提交任务时的副本，只用于取 ID，
+// 不整体写回，避免覆盖导入期间在设置窗口中修改的名称、容量上限和存储后端
+func (in *Ingester) markChanged(ds *models.Dataset, results []Result) {
+	for _, r := range results {
+		if r.Image != nil {
+			if err := in.repo.MarkDatasetChanged(ds.ID, r.Image.SHA256); err != nil {
+				fmt.Println("更新数据集状态失败:", err)
+			}
+			return
+		}
+	}
+}
+
+// importFile 暂存、校验、查重、入库
+// existing 非空表示与已有图片内容重复，此时返回的图片为空表示按策略跳过
+func (in *Ingester) importFile(ctx context.Context, ds *models.Dataset, item Item, opts Options, report func(float64)) (img, existing *models.Image, err error) {
+	info, err := os.Stat(item.Path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("读取文件失败: %w", err)
+	}
+	if !info.Mode().IsRegular() {
+		return nil, nil, fmt.Errorf("不是普通文件: %s", item.Path)
+	}
+	if info.Size() == 0 {
+		return nil, nil, errors.New("文件为空")
+	}
+
+	// 1. 复制到缓存目录暂存，避免源文件在导入过程中被修改，复制进度占总进度的 60%
+	staged, err := in.stage(ctx, item.Path, func(done, total int64) {
+		if total > 0 {
+			report(0.6 * float64(done) / float64(total))
+		}
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	defer os.Remove(staged) // 成功时已被移走，失败时清理
+
+	// 2. 读取元数据，按规则校验并完整解码
+	img, err = DescribeImage(staged, item.Source)
+	if err != nil {
+		return nil, nil, err
+	}
+	img.DatasetID = ds.ID
+	img.OriginalName = item.Name
+	img.Label = item.Label
+	img.Provenance, img.License, img.Attributes = item.Meta.Provenance, item.Meta.License, item.Meta.attributesJSON()
+	var rules *conf.ValidationConfig
+	if in.cfg != nil {
+		rules = in.cfg.Validation
+	}
+	if err := Validate(img, rules); err != nil {
+		return nil, nil, err
+	}
+	decoded, err := decodeImage(staged)
+	if err != nil {
+		return nil, nil, err
+	}
+	img.OriginalSHA256 = img.SHA256
+	setHashes(img, decoded)
+	report(0.8)
+	if err := ctx.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	// 3. 按内容哈希在所有数据集中查重，同内容的图片加锁直到入库完成
+	// 持有锁期间不再回调 report：暂停时 report 会阻塞，同内容的其他任务会一直等待这把锁
+	unlock, err := in.hashLocks.Lock(ctx, img.OriginalSHA256)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer unlock()
+	found, err := in.repo.FindImageBySHA256(img.SHA256)
+	switch {
+	case errors.Is(err, database.ErrNotFound):
+	case err != nil:
+		return nil, nil, err
+	default:
+		existing = found
+		img.DuplicateOf = existing.ID
+		if existing.DuplicateOf != 0 {
+			img.DuplicateOf = existing.DuplicateOf
+		}
+		switch opts.Duplicates {
+		case DuplicateLink:
+			// 恢复的任务上次可能已写入引用记录，只是没来得及删除日志，不再重复引用
+			if item.recovered {
+				if linked, err := in.linkedImage(ds, img.SHA256, existing.Path); err != nil || linked != nil {
+					return linked, existing, err
+				}
+			}
+			// 不复制文件，直接引用已有文件
+			img.FileName = existing.FileName
+			img.Path = existing.Path
+			if err := in.repo.AddImage(img); err != nil {
+				return nil, existing, err
+			}
+			in.generateThumbnails(img, decoded)
+			return img, existing, nil
+		case DuplicateCopy:
+			// 继续按正常流程保存副本
+		default:
+			return nil, existing, nil
+		}
+	}
+
+	// 4. 按数据集设置统一格式，转换后的文件替代暂存文件
+	if ds.NormalizeFormat != NormalizeNone {
+		normalized, oriented, err := normalize(ds, staged, img, decoded)
+		if err != nil {
+			return nil, existing, err
+		}
+		defer os.Remove(normalized)
+		staged, decoded = normalized, oriented
+		setHashes(img, decoded)
+		item.Name = strings.TrimSuffix(item.Name, filepath.Ext(item.Name)) + formatExt(img.Format)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, existing, err
+	}
+
+	// 5. 移动到数据集目录，保留相对子目录
+	dir, err := in.datasetDir(ds, item.SubDir)
+	if err != nil {
+		return nil, existing, err
+	}
+	dest, err := in.targetPath(ds, dir, img, item)
+	if err != nil {
+		return nil, existing, err
+	}
+	// 先在任务日志中记录保存路径，程序中途退出时重启后据此判断是否已入库、清理未完成的文件
+	if item.journalID != 0 {
+		if err := in.repo.SetQueueTarget(item.journalID, dest); err != nil {
+			os.Remove(dest)
+			return nil, existing, err
+		}
+	}
+	if err := moveFile(staged, dest); err != nil {
+		os.Remove(dest) // 删除占位文件
+		return nil, existing, err
+	}
+
+	// 6. 写入图片记录
+	img.FileName = filepath.Base(dest)
+	img.Path = dest
+	if err := in.repo.AddImage(img); err != nil {
+		os.Remove(dest)
+		return nil, existing, err
+	}
+	in.generateThumbnails(img, decoded)
+	return img, existing, nil
+}
+
+// linkedImage 数据集中内容为 sha256、引用 path 文件的图片记录，没有时返回 nil
+func (in *Ingester) linkedImage(ds *models.Dataset, sha256, path string) (*models.Image, error) {
+	images, err := in.repo.ListImages(ds.ID)
+	if err != nil {
+		return nil, err
+	}
+	for _, img := range images {
+		if img.Path == path && (img.SHA256 == sha256 || img.OriginalSHA256 == sha256) {
+			return img, nil
+		}
+	}
+	return nil, nil
+}
+
+// maxRenameAttempts 自动重命名时目标文件已存在的最大重试次数，超过后追加 _1、_2 后缀
+const maxRenameAttempts = 100
+
+// targetPath 计算图片在数据集目录中的保存路径并创建占位文件，避免并发导入时选中同一路径
+// 开启自动重命名时按模板生成文件名：模板包含 {seq} 时每次从数据库取新序号，
+// 序号与目录中已有文件冲突（如手动放入的文件）时继续取下一个
+func (in *Ingester) targetPath(ds *models.Dataset, dir string, img *models.Image, item Item) (string, error) {
+	// 剪贴板图片没有原始文件名，未开启自动重命名时也按模板生成
+	if item.Source != models.SourceClipboard && (in.cfg == nil || !in.cfg.AutoRename) {
+		return uniquePath(dir, item.Name)
+	}
+	key := DefaultRenameTemplate
+	if in.cfg != nil && strings.TrimSpace(in.cfg.AutoRenameKey) != "" {
+		key = in.cfg.AutoRenameKey
+	}
+	tmpl, err := ParseRenameTemplate(key)
+	if err != nil {
+		return "", fmt.Errorf("重命名模板无效: %w", err)
+	}
+
+	ext := renameExtension(item.Name, img.Format)
+	vars := RenameVars{
+		Dataset: ds.Name,
+		Name:    strings.TrimSuffix(item.Name, filepath.Ext(item.Name)),
+		Ext:     ext,
+		Time:    time.Now(),
+		SHA256:  img.SHA256,
+		Label:   item.Label,
+		Format:  img.Format,
+		Width:   img.Width,
+		Height:  img.Height,
+	}
+	name := tmpl.Render(vars)
+	for attempt := 0; tmpl.UsesSequence() && attempt < maxRenameAttempts; attempt++ {
+		if vars.Seq, err = in.repo.NextSequence(ds.ID); err != nil {
+			return "", err
+		}
+		name = tmpl.Render(vars)
+		candidate := filepath.Join(dir, name)
+		if ok, err := reservePath(candidate); err != nil {
+			return "", err
+		} else if ok {
+			return candidate, nil
+		}
+	}
+	return uniquePath(dir, name)
+}
+
+// stage 将源文件复制到缓存目录下的 staging 子目录，复制过程中响应 ctx 取消并回调进度
+func (in *Ingester) stage(ctx context.Context, path string, progress ProgressFunc) (string, error) {
+	dir := filepath.Join(in.tmpDir(), "staging")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", fmt.Errorf("创建缓存目录失败: %w", err)
+	}
+	src, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("暂存文件失败: %w", err)
+	}
+	defer src.Close()
+	var total int64 = -1
+	if info, err := src.Stat(); err == nil {
+		total = info.Size()
+	}
+
+	staged := filepath.Join(dir, randomName()+"_"+filepath.Base(path))
+	reader := &progressReader{r: &contextReader{ctx: ctx, r: src}, total: total, progress: progress}
+	if err := writeFile(reader, staged); err != nil {
+		if ctx.Err() != nil {
+			return "", ctx.Err()
+		}
+		return "", fmt.Errorf("暂存文件失败: %w", err)
+	}
+	return staged, nil
+}
+
+// contextReader 每次读取前检查 ctx，取消后返回 ctx.Err()
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (c *contextReader) Read(b []byte) (int, error) {
+	if err := c.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return c.r.Read(b)
+}
+
+// tmpDir 缓存目录，未配置时使用系统临时目录
+func (in *Ingester) tmpDir() string {
+	if in.cfg != nil && in.cfg.TmpDir != "" {
+		return in.cfg.TmpDir
+	}
+	return filepath.Join(os.TempDir(), "dataset-sync")
+}
+
+// datasetDir 数据集在存放目录下的文件夹（含子目录），不存在时创建
+func (in *Ingester) datasetDir(ds *models.Dataset, subDir string) (string, error) {
+	if in.cfg == nil || in.cfg.SaveDir == "" {
+		return "", errors.New("未设置文件存放目录")
+	}
+	dir := filepath.Join(in.cfg.SaveDir, safeName(ds.Name))
+	// 子目录逐级清理，防止 .. 跳出数据集目录
+	for _, part := range strings.Split(filepath.ToSlash(subDir), "/") {
+		if part != "" && part != "." {
+			dir = filepath.Join(dir, safeName(part))
+		}
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", fmt.Errorf("创建数据集目录失败: %w", err)
+	}
+	return dir, nil
+}
+
+// Succeeded 统计导入成功的文件数（不含被跳过的重复图片）
+func Succeeded(results []Result) int {
+	count := 0
+	for _, r := range results {
+		if r.Err == nil && r.Image != nil {
+			count++
+		}
+	}
+	return count
+}
+
+// Duplicates 统计内容重复的文件数
+func Duplicates(results []Result) int {
+	count := 0
+	for _, r := range results {
+		if r.Duplicate {
+			count++
+		}
+	}
+	return count
+}
+
+// safeName 替换文件名中不允许出现的字符
+func safeName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`<>:"/\|?*`, r) || r < 32 {
+			return '_'
+		}
+		return r
+	}, strings.TrimSpace(name))
+	if name == "" || name == "." || name == ".." {
+		return "_"
+	}
+	return name
+}
+
+// uniquePath 在目录中为文件名找一个不冲突的路径并创建占位文件，冲突时追加 _1、_2 ...
+func uniquePath(dir, name string) (string, error) {
+	name = safeName(name)
+	ext := filepath.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+	candidate := filepath.Join(dir, name)
+	for i := 1; ; i++ {
+		if ok, err := reservePath(candidate); err != nil {
+			return "", err
+		} else if ok {
+			return candidate, nil
+		}
+		candidate = filepath.Join(dir, fmt.Sprintf("%s_%d%s", base, i, ext))
+	}
+}
+
+// reservePath 以独占方式创建空的占位文件，文件已存在时返回 false
+// 随后的 moveFile 会覆盖占位文件，失败时调用方负责删除
+func reservePath(path string) (bool, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if errors.Is(err, os.ErrExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("创建文件失败: %w", err)
+	}
+	f.Close()
+	return true, nil
+}
+
+// urlFileName 链接对应的显示文件名
+func urlFileName(rawURL string) string {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil {
+		return rawURL
+	}
+	name := path.Base(u.Path)
+	if name == "." || name == "/" {
+		return u.Host
+	}
+	return name
+}
+
+// randomName 生成随机文件名前缀
+func randomName() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// copyFile 复制文件
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	return writeFile(in, dst)
+}
+
+// writeFile 将 r 的内容写入新文件 dst，失败时删除不完整的文件
+func writeFile(r io.Reader, dst string) error {
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err = io.Copy(out, r); err != nil {
+		out.Close()
+		os.Remove(dst)
+		return err
+	}
+	if err = out.Close(); err != nil {
+		os.Remove(dst)
+	}
+	return err
+}
+
+// moveFile 移动文件，跨磁盘时先复制为 .part 文件再重命名，保证目标文件完整
+func moveFile(src, dst string) error {
+	if err := os.Rename(src, dst); err == nil {
+		return nil
+	}
+	part := dst + ".part"
+	if err := copyFile(src, part); err != nil {
+		return fmt.Errorf("移动文件失败: %w", err)
+	}
+	if err := os.Rename(part, dst); err != nil {
+		os.Remove(part)
+		return fmt.Errorf("移动文件失败: %w", err)
+	}
+	return os.Remove(src)
+}
@@ -0,0 +1,111 @@
+package ingest
+
+import (
+	"bytes"
+	"context"
+	"dataset-sync/conf"
+	"dataset-sync/database"
+	"dataset-sync/models"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// newTestIngester 使用内存数据库、存放目录和缓存目录都在临时目录中的导入器
+func newTestIngester(t *testing.T) (*database.MemoryRepository, *Ingester) {
+	t.Helper()
+	repo := database.NewMemoryRepository()
+	return repo, New(repo, &conf.DatasetConfig{SaveDir: t.TempDir(), TmpDir: t.TempDir()})
+}
+
+// writeTestPNG 在 dir 中写入一张可以解码的图片，shade 不同时内容不同
+func writeTestPNG(t *testing.T, dir, name string, shade uint8) string {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, 4, 4))
+	img.Set(0, 0, color.RGBA{R: shade, A: 255})
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestIngestDuplicatePolicies(t *testing.T) {
+	tests := []struct {
+		name      string
+		policy    DuplicatePolicy
+		status    models.UploadStatus
+		imported  bool // 是否写入图片记录
+		ownedFile bool // 是否在数据集目录中保存副本
+	}{
+		{"default", "", models.UploadSkippedDuplicate, false, false},
+		{"skip", DuplicateSkip, models.UploadSkippedDuplicate, false, false},
+		{"link", DuplicateLink, models.UploadSucceeded, true, false},
+		{"copy", DuplicateCopy, models.UploadSucceeded, true, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, in := newTestIngester(t)
+			cats, _ := in.EnsureDataset("cats")
+			dogs, _ := in.EnsureDataset("dogs")
+			src := t.TempDir()
+
+			first := in.ingest(context.Background(), cats, FileItem(writeTestPNG(t, src, "a.png", 1), models.SourceFile), Options{}, nil, nil)
+			if first.Err != nil || first.Duplicate {
+				t.Fatalf("first import = %+v", first)
+			}
+
+			// 另一个数据集中导入内容相同、名称不同的文件
+			result := in.ingest(context.Background(), dogs, FileItem(writeTestPNG(t, src, "b.png", 1), models.SourceFile), Options{Duplicates: tt.policy}, nil, nil)
+			if result.Err != nil {
+				t.Fatal(result.Err)
+			}
+			if !result.Duplicate || result.Existing != first.Image.Path {
+				t.Fatalf("duplicate=%v existing=%q, want the first image %q", result.Duplicate, result.Existing, first.Image.Path)
+			}
+			if result.Record.UploadStatus != tt.status || !result.Record.Duplicate {
+				t.Fatalf("record = %+v, want status %s marked as duplicate", result.Record, tt.status)
+			}
+
+			images, _ := repo.ListImages(dogs.ID)
+			if !tt.imported {
+				if result.Image != nil || len(images) != 0 {
+					t.Fatalf("skipped duplicate wrote %d images", len(images))
+				}
+				return
+			}
+			if len(images) != 1 || images[0].DuplicateOf != first.Image.ID {
+				t.Fatalf("images = %+v, want one image pointing at the first", images)
+			}
+			owned := images[0].Path != first.Image.Path
+			if owned != tt.ownedFile {
+				t.Fatalf("path = %s, first = %s, want a separate copy: %v", images[0].Path, first.Image.Path, tt.ownedFile)
+			}
+			if _, err := os.Stat(images[0].Path); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestIngestDifferentContentIsNotDuplicate(t *testing.T) {
+	repo, in := newTestIngester(t)
+	cats, _ := in.EnsureDataset("cats")
+	src := t.TempDir()
+	for i, name := range []string{"a.png", "b.png"} {
+		result := in.ingest(context.Background(), cats, FileItem(writeTestPNG(t, src, name, uint8(i+1)), models.SourceFile), Options{}, nil, nil)
+		if result.Err != nil || result.Duplicate {
+			t.Fatalf("import %s = %+v", name, result)
+		}
+	}
+	if images, _ := repo.ListImages(cats.ID); len(images) != 2 {
+		t.Fatalf("%d images, want 2", len(images))
+	}
+}
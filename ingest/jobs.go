@@ -0,0 +1,659 @@
+package ingest
+
+import (
+	"context"
+	"dataset-sync/database"
+	"dataset-sync/models"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// JobState 导入任务状态，界面通过 Label 显示中文
+type JobState string
+
+const (
+	JobQueued    JobState = "queued"    // 排队中（含等待重试）
+	JobRunning   JobState = "running"   // 进行中
+	JobDone      JobState = "done"      // 已完成，结果见 JobInfo.Result
+	JobCancelled JobState = "cancelled" // 已取消
+)
+
+// Label 状态的中文名称
+func (s JobState) Label() string {
+	switch s {
+	case JobQueued:
+		return "排队中"
+	case JobRunning:
+		return "进行中"
+	case JobDone:
+		return "已完成"
+	case JobCancelled:
+		return "已取消"
+	}
+	return string(s)
+}
+
+const (
+	DefaultWorkers = 4  // 默认同时导入的文件数
+	MaxWorkers     = 16 // 同时导入的文件数上限
+
+	RetryBaseDelay = 2 * time.Second // 第一次重试前的等待时间，之后每次翻倍
+	MaxRetryDelay  = 5 * time.Minute // 重试等待时间上限
+)
+
+// Job 一个文件或链接的导入任务
+type Job struct {
+	id        int64
+	dataset   *models.Dataset
+	item      Item
+	url       string // 非空时先下载再导入
+	opts      Options
+	size      int64     // 本地文件大小，提交时统计，用于容量检查
+	notBefore time.Time // 重试任务的最早开始时间
+	batch     *jobBatch
+	state     JobState
+	progress  float64
+	result    Result
+	ctx       context.Context
+	cancel    context.CancelFunc
+}
+
+// JobInfo 任务的只读快照，供界面展示
+type JobInfo struct {
+	ID        int64
+	DatasetID int
+	Dataset   string
+	Name      string
+	Source    string
+	State     JobState
+	Progress  float64   // 0-1
+	NotBefore time.Time // 排队中的重试任务最早开始的时间
+	Result    Result    // State 为已完成或已取消时有效
+}
+
+// jobBatch 一次提交的一组任务，全部结束后回调 done
+// 排队中的任务可以改到其他数据集，结束时按任务最终所在的数据集分别更新
+type jobBatch struct {
+	jobs      []*Job
+	results   []Result
+	remaining int
+	done      func([]Result)
+}
+
+// Manager 导入任务管理器：按设置的并发数执行任务，支持暂停、继续和取消
+// 暂停时不再开始新任务，进行中的任务在下一次汇报进度时等待继续
+type Manager struct {
+	in *Ingester
+
+	mu      sync.Mutex
+	cond    *sync.Cond
+	workers int
+	running int
+	paused  bool
+	queue   []*Job
+	jobs    []*Job // 本次运行中提交的全部任务，按提交顺序
+	nextID  int64
+	wakeAt  time.Time // 已安排的唤醒时间，等待重试任务到期
+}
+
+// NewManager 创建任务管理器并开始调度，workers 为 0 时使用配置中的并发数
+func NewManager(in *Ingester, workers int) *Manager {
+	if workers <= 0 && in.cfg != nil {
+		workers = in.cfg.UploadWorkers
+	}
+	m := &Manager{in: in, workers: clampWorkers(workers)}
+	m.cond = sync.NewCond(&m.mu)
+	go m.dispatch()
+	return m
+}
+
+// clampWorkers 并发数限制在 1 到 MaxWorkers 之间，未设置时使用默认值
+func clampWorkers(workers int) int {
+	switch {
+	case workers <= 0:
+		return DefaultWorkers
+	case workers > MaxWorkers:
+		return MaxWorkers
+	}
+	return workers
+}
+
+// SubmitItems 提交本地文件导入任务，全部结束后在后台 goroutine 中回调 done（可以为空）
+// 超出数据集容量上限或磁盘剩余空间不足时整批拒绝，返回 QuotaError，不回调 done
+func (m *Manager) SubmitItems(ds *models.Dataset, items []Item, opts Options, done func([]Result)) error {
+	jobs := make([]*Job, 0, len(items))
+	for _, item := range items {
+		jobs = append(jobs, &Job{dataset: ds, item: item, opts: opts})
+	}
+	if err := m.preflight(ds, jobs); err != nil {
+		return err
+	}
+	m.submit(jobs, done)
+	return nil
+}
+
+// SubmitURLs 提交链接下载导入任务，容量检查同 SubmitItems
+func (m *Manager) SubmitURLs(ds *models.Dataset, urls []string, opts Options, done func([]Result)) error {
+	jobs := make([]*Job, 0, len(urls))
+	for _, rawURL := range urls {
+		item := Item{Name: urlFileName(rawURL), Origin: rawURL, Source: models.SourceURL}
+		jobs = append(jobs, &Job{dataset: ds, item: item, url: rawURL, opts: opts})
+	}
+	if err := m.preflight(ds, jobs); err != nil {
+		return err
+	}
+	m.submit(jobs, done)
+	return nil
+}
+
+// preflight 提交前检查容量：本批任务加上同一数据集中尚未结束的任务，超出上限或磁盘空间不足时整批拒绝
+// 链接任务的大小在下载前未知，只计数量
+func (m *Manager) preflight(ds *models.Dataset, jobs []*Job) error {
+	var largest int64
+	batch := BatchSize{Count: len(jobs)}
+	for _, job := range jobs {
+		if job.url != "" {
+			continue
+		}
+		if info, err := os.Stat(job.item.Path); err == nil {
+			job.size = info.Size()
+			batch.Bytes += job.size
+			largest = max(largest, job.size)
+		}
+	}
+
+	m.mu.Lock()
+	count, size := m.pending(ds.ID)
+	workers := m.workers
+	m.mu.Unlock()
+	batch.Count += count
+	batch.Bytes += size
+	batch.Staging = min(batch.Bytes, largest*int64(workers))
+	return m.in.CheckQuota(ds, batch)
+}
+
+// pending 数据集中排队中和进行中的任务数及本地文件总大小，调用方需持有锁
+func (m *Manager) pending(datasetID int) (int, int64) {
+	var (
+		count int
+		size  int64
+	)
+	for _, job := range m.jobs {
+		if job.dataset.ID == datasetID && (job.state == JobQueued || job.state == JobRunning) {
+			count++
+			size += job.size
+		}
+	}
+	return count, size
+}
+
+// submit 将一组任务写入任务日志并加入队列
+func (m *Manager) submit(jobs []*Job, done func([]Result)) {
+	m.journal(jobs)
+	m.enqueue(jobs, done)
+}
+
+// enqueue 将一组任务加入队列
+func (m *Manager) enqueue(jobs []*Job, done func([]Result)) {
+	batch := &jobBatch{jobs: jobs, remaining: len(jobs), done: done}
+	if len(jobs) == 0 {
+		if done != nil {
+			go done(nil)
+		}
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, job := range jobs {
+		m.nextID++
+		job.id = m.nextID
+		job.batch = batch
+		job.state = JobQueued
+		job.ctx, job.cancel = context.WithCancel(context.Background())
+		m.queue = append(m.queue, job)
+		m.jobs = append(m.jobs, job)
+	}
+	m.cond.Broadcast()
+}
+
+// dispatch 调度循环：有空闲名额且未暂停时取出最早可以开始的任务执行
+func (m *Manager) dispatch() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for {
+		job := m.nextReady()
+		if job == nil {
+			m.cond.Wait()
+			continue
+		}
+		m.start(job)
+	}
+}
+
+// nextReady 从队列中取出第一个可以开始的任务，没有时返回 nil，调用方需持有锁
+// 队列中只有等待重试的任务时，安排在最早到期的时间唤醒调度循环
+func (m *Manager) nextReady() *Job {
+	if m.paused || len(m.queue) == 0 || m.running >= m.workers {
+		return nil
+	}
+	now := time.Now()
+	var earliest time.Time
+	for i, job := range m.queue {
+		if !job.notBefore.After(now) {
+			m.queue = append(m.queue[:i], m.queue[i+1:]...)
+			return job
+		}
+		if earliest.IsZero() || job.notBefore.Before(earliest) {
+			earliest = job.notBefore
+		}
+	}
+	if m.wakeAt.IsZero() || earliest.Before(m.wakeAt) {
+		m.wakeAt = earliest
+		time.AfterFunc(earliest.Sub(now), func() {
+			m.mu.Lock()
+			defer m.mu.Unlock()
+			if !m.wakeAt.After(time.Now()) {
+				m.wakeAt = time.Time{}
+			}
+			m.cond.Broadcast()
+		})
+	}
+	return nil
+}
+
+// start 开始执行任务，调用方需持有锁
+func (m *Manager) start(job *Job) {
+	job.state = JobRunning
+	m.running++
+	go m.run(job)
+}
+
+// run 执行单个任务
+func (m *Manager) run(job *Job) {
+	report := func(progress float64) {
+		m.mu.Lock()
+		job.progress = progress
+		m.mu.Unlock()
+		m.waitIfPaused(job.ctx)
+	}
+
+	var result Result
+	if job.url != "" {
+		// 下载占前一半进度，导入占后一半
+		item, err := fetchItem(job.ctx, m.in.Fetcher(), job.url, func(done, total int64) {
+			if total > 0 {
+				report(0.5 * float64(done) / float64(total))
+			}
+		})
+		if err != nil && job.ctx.Err() != nil {
+			err = job.ctx.Err() // 下载被取消时统一记为已取消
+		}
+		item.journalID, item.history, item.recovered = job.item.journalID, job.item.history, job.item.recovered
+		result = m.in.ingest(job.ctx, job.dataset, item, job.opts, err, func(p float64) { report(0.5 + 0.5*p) })
+		if item.Path != "" {
+			os.Remove(item.Path)
+		}
+	} else {
+		result = m.in.ingest(job.ctx, job.dataset, job.item, job.opts, nil, report)
+	}
+	m.finish(job, result, true)
+}
+
+// waitIfPaused 暂停期间阻塞，任务被取消时立即返回
+func (m *Manager) waitIfPaused(ctx context.Context) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for m.paused && ctx.Err() == nil {
+		m.cond.Wait()
+	}
+}
+
+// finish 记录任务结果，一组任务全部结束后更新数据集并回调；started 为 true 时释放任务占用的并发名额
+func (m *Manager) finish(job *Job, result Result, started bool) {
+	m.mu.Lock()
+	job.result = result
+	job.progress = 1
+	job.state = JobDone
+	if job.ctx.Err() != nil && result.Err != nil {
+		job.state = JobCancelled
+	}
+	job.cancel()
+	if started {
+		m.running--
+	}
+	batch := job.batch
+	batch.results = append(batch.results, result)
+	batch.remaining--
+	last := batch.remaining == 0
+	m.cond.Broadcast()
+	m.mu.Unlock()
+
+	if id := job.item.journalID; id != 0 {
+		if err := m.in.repo.DeleteQueueItem(id); err != nil {
+			fmt.Println("删除导入任务日志失败:", err)
+		}
+	}
+	if last {
+		for _, group := range batch.byDataset() {
+			m.in.finishBatch(group.dataset, group.results)
+		}
+		if batch.done != nil {
+			batch.done(batch.results)
+		}
+	}
+}
+
+// datasetResults 同一数据集的任务结果
+type datasetResults struct {
+	dataset *models.Dataset
+	results []Result
+}
+
+// byDataset 按任务最终所在的数据集分组结果，批次全部结束后调用
+func (b *jobBatch) byDataset() []*datasetResults {
+	var groups []*datasetResults
+	index := make(map[int]*datasetResults)
+	for _, job := range b.jobs {
+		group := index[job.dataset.ID]
+		if group == nil {
+			group = &datasetResults{dataset: job.dataset}
+			index[job.dataset.ID] = group
+			groups = append(groups, group)
+		}
+		group.results = append(group.results, job.result)
+	}
+	return groups
+}
+
+// Retry 将失败或取消的上传记录重新加入队列，按已尝试次数指数退避后开始，返回加入队列的数量
+// 记录先标记为排队中，导入结束后更新原记录并累加尝试次数；不可重试或数据集已删除的记录跳过
+// 按数据集分组检查容量，某个数据集超出上限时返回 QuotaError，之前的分组已加入队列
+func (m *Manager) Retry(records []*models.UploadDetails, opts Options, done func([]Result)) (int, error) {
+	var (
+		order    []int
+		groups   = make(map[int][]*Job)
+		datasets = make(map[int]*models.Dataset)
+		now      = time.Now()
+	)
+	for _, record := range records {
+		if !record.Retryable() {
+			continue
+		}
+		ds, ok := datasets[record.DatasetID]
+		if !ok {
+			var err error
+			if ds, err = m.in.repo.GetDataset(record.DatasetID); errors.Is(err, database.ErrNotFound) {
+				fmt.Printf("数据集 %s 已删除，跳过重试: %s\n", record.DatasetName, record.SourcePath)
+				continue
+			} else if err != nil {
+				return 0, err
+			}
+			datasets[record.DatasetID] = ds
+			order = append(order, ds.ID)
+		}
+
+		record := *record
+		record.UploadStatus = models.UploadQueued
+		job := &Job{dataset: ds, opts: opts, notBefore: now.Add(retryDelay(record.Attempts))}
+		if lower := strings.ToLower(record.SourcePath); strings.HasPrefix(lower, "http://") || strings.HasPrefix(lower, "https://") {
+			job.url = record.SourcePath
+			job.item = Item{Name: urlFileName(record.SourcePath), Origin: record.SourcePath, Source: models.SourceURL}
+		} else {
+			job.item = FileItem(record.SourcePath, models.SourceFile)
+		}
+		job.item.history = &record
+		groups[ds.ID] = append(groups[ds.ID], job)
+	}
+
+	count := 0
+	for _, id := range order {
+		jobs := groups[id]
+		if err := m.preflight(datasets[id], jobs); err != nil {
+			return count, err
+		}
+		for _, job := range jobs {
+			if err := m.in.repo.UpdateUploadHistory(job.item.history); err != nil {
+				return count, err
+			}
+		}
+		count += len(jobs)
+		m.submit(jobs, done)
+	}
+	return count, nil
+}
+
+// retryDelay 第 attempts 次失败后的重试等待时间
+func retryDelay(attempts int) time.Duration {
+	delay := RetryBaseDelay
+	for i := 1; i < attempts && delay < MaxRetryDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, MaxRetryDelay)
+}
+
+// Reassign 把排队中的任务改到数据集 ds，任务已开始或已结束时返回错误，超出 ds 的容量上限时返回 QuotaError
+func (m *Manager) Reassign(id int64, ds *models.Dataset) error {
+	errStarted := errors.New("任务已开始或已结束，无法修改目标数据集")
+	m.mu.Lock()
+	job := m.queuedJob(id)
+	if job == nil {
+		m.mu.Unlock()
+		return errStarted
+	}
+	if job.dataset.ID == ds.ID {
+		m.mu.Unlock()
+		return nil
+	}
+	count, size := m.pending(ds.ID)
+	batch := BatchSize{Count: count + 1, Bytes: size + job.size, Staging: job.size}
+	m.mu.Unlock()
+	if err := m.in.CheckQuota(ds, batch); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	if m.queuedJob(id) == nil {
+		m.mu.Unlock()
+		return errStarted
+	}
+	job.dataset = ds
+	journalID := job.item.journalID
+	m.mu.Unlock()
+
+	// 任务可能在此期间开始并结束，日志已删除时忽略
+	if journalID != 0 {
+		if err := m.in.repo.SetQueueDataset(journalID, ds.ID); err != nil && !errors.Is(err, database.ErrNotFound) {
+			fmt.Println("更新导入任务日志失败:", err)
+		}
+	}
+	return nil
+}
+
+// queuedJob 查找排队中的任务，调用方需持有锁
+func (m *Manager) queuedJob(id int64) *Job {
+	for _, job := range m.queue {
+		if job.id == id {
+			return job
+		}
+	}
+	return nil
+}
+
+// Cancel 取消任务：进行中的任务尽快中止，排队中的任务直接记为已取消
+func (m *Manager) Cancel(id int64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i, job := range m.queue {
+		if job.id == id {
+			m.queue = append(m.queue[:i], m.queue[i+1:]...)
+			m.cancelQueued(job)
+			return
+		}
+	}
+	for _, job := range m.jobs {
+		if job.id == id && job.state == JobRunning {
+			job.cancel()
+			m.cond.Broadcast() // 唤醒暂停中等待的任务
+			return
+		}
+	}
+}
+
+// CancelAll 取消全部排队中和进行中的任务
+func (m *Manager) CancelAll() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, job := range m.queue {
+		m.cancelQueued(job)
+	}
+	m.queue = nil
+	for _, job := range m.jobs {
+		if job.state == JobRunning {
+			job.cancel()
+		}
+	}
+	m.cond.Broadcast()
+}
+
+// cancelQueued 取消尚未开始的任务，调用方需持有锁
+// 不占用并发名额，在后台写入已取消的上传记录并计入所在批次
+func (m *Manager) cancelQueued(job *Job) {
+	job.cancel()
+	job.state = JobCancelled
+	go func() {
+		result := m.in.ingest(job.ctx, job.dataset, job.item, job.opts, context.Canceled, nil)
+		m.finish(job, result, false)
+	}()
+}
+
+// Pause 暂停：不再开始新任务，进行中的任务在当前步骤完成后等待
+func (m *Manager) Pause() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.paused = true
+}
+
+// Resume 继续执行
+func (m *Manager) Resume() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.paused = false
+	m.cond.Broadcast()
+}
+
+// Paused 是否处于暂停状态
+func (m *Manager) Paused() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.paused
+}
+
+// Workers 当前并发数
+func (m *Manager) Workers() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.workers
+}
+
+// SetWorkers 修改并发数，减少时进行中的任务不受影响
+func (m *Manager) SetWorkers(workers int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.workers = clampWorkers(workers)
+	m.cond.Broadcast()
+}
+
+// Jobs 返回全部任务的快照，按提交顺序
+func (m *Manager) Jobs() []JobInfo {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	infos := make([]JobInfo, 0, len(m.jobs))
+	for _, job := range m.jobs {
+		info := JobInfo{
+			ID:        job.id,
+			DatasetID: job.dataset.ID,
+			Dataset:   job.dataset.Name,
+			Name:      job.item.Name,
+			Source:    job.item.Origin,
+			State:     job.state,
+			Progress:  job.progress,
+			NotBefore: job.notBefore,
+		}
+		if job.state == JobDone || job.state == JobCancelled {
+			info.Result = job.result
+		}
+		infos = append(infos, info)
+	}
+	return infos
+}
+
+// Active 排队中和进行中的任务数
+func (m *Manager) Active() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.queue) + m.running
+}
+
+// ClearFinished 从任务列表中移除已结束的任务
+func (m *Manager) ClearFinished() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	jobs := m.jobs[:0]
+	for _, job := range m.jobs {
+		if job.state == JobQueued || job.state == JobRunning {
+			jobs = append(jobs, job)
+		}
+	}
+	clear(m.jobs[len(jobs):])
+	m.jobs = jobs
+}
+
+// keyedMutex 按键加锁，不同键之间互不影响
+type keyedMutex struct {
+	mu    sync.Mutex
+	locks map[string]*keyedLock
+}
+
+type keyedLock struct {
+	held chan struct{} // 容量为 1，写入即加锁，等待时可以被 ctx 取消
+	refs int
+}
+
+func newKeyedMutex() *keyedMutex {
+	return &keyedMutex{locks: make(map[string]*keyedLock)}
+}
+
+// Lock 锁定 key，返回解锁函数；等待期间 ctx 取消时放弃并返回 ctx.Err()
+func (k *keyedMutex) Lock(ctx context.Context, key string) (func(), error) {
+	k.mu.Lock()
+	l, ok := k.locks[key]
+	if !ok {
+		l = &keyedLock{held: make(chan struct{}, 1)}
+		k.locks[key] = l
+	}
+	l.refs++
+	k.mu.Unlock()
+
+	release := func() {
+		k.mu.Lock()
+		if l.refs--; l.refs == 0 {
+			delete(k.locks, key)
+		}
+		k.mu.Unlock()
+	}
+	select {
+	case l.held <- struct{}{}:
+	case <-ctx.Done():
+		release()
+		return nil, ctx.Err()
+	}
+	return func() {
+		<-l.held
+		release()
+	}, nil
+}
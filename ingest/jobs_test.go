@@ -0,0 +1,119 @@
+package ingest
+
+import (
+	"context"
+	"crypto/sha256"
+	"dataset-sync/models"
+	"encoding/hex"
+	"errors"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestKeyedMutexLockHonoursContext(t *testing.T) {
+	k := newKeyedMutex()
+	unlock, err := k.Lock(context.Background(), "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 其他键不受影响
+	unlockB, err := k.Lock(context.Background(), "b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	unlockB()
+
+	// 等待同一个键时可以被取消
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := k.Lock(ctx, "a"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v, want the context error while the key is held", err)
+	}
+
+	unlock()
+	unlock, err = k.Lock(context.Background(), "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	unlock()
+	if len(k.locks) != 0 {
+		t.Fatalf("%d keys left after unlocking", len(k.locks))
+	}
+}
+
+// waitFor 等待条件成立，超时后终止测试
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestCancelQueuedJobWithoutWorkerSlot(t *testing.T) {
+	repo, in := newTestIngester(t)
+	ds, _ := in.EnsureDataset("cats")
+	src := t.TempDir()
+	blocked := writeTestPNG(t, src, "a.png", 1)
+
+	// 占住同内容图片的锁，唯一的并发名额被第一个任务一直占用
+	data, _ := os.ReadFile(blocked)
+	sum := sha256.Sum256(data)
+	unlock, err := in.hashLocks.Lock(context.Background(), hex.EncodeToString(sum[:]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := NewManager(in, 1)
+	firstDone := make(chan []Result, 1)
+	if err := m.SubmitItems(ds, []Item{FileItem(blocked, models.SourceFile)}, Options{}, func(r []Result) { firstDone <- r }); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, "the first job to start", func() bool { return m.Jobs()[0].State == JobRunning })
+
+	queuedDone := make(chan []Result, 1)
+	items := []Item{FileItem(writeTestPNG(t, src, "b.png", 2), models.SourceFile)}
+	if err := m.SubmitItems(ds, items, Options{}, func(r []Result) { queuedDone <- r }); err != nil {
+		t.Fatal(err)
+	}
+	m.Cancel(m.Jobs()[1].ID)
+
+	// 排队中的任务不等并发名额，直接结束
+	select {
+	case results := <-queuedDone:
+		if len(results) != 1 || results[0].Record.UploadStatus != models.UploadCancelled {
+			t.Fatalf("results = %+v, want one cancelled record", results)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("cancelled job waited for the busy worker")
+	}
+	if jobs := m.Jobs(); jobs[0].State != JobRunning || jobs[1].State != JobCancelled {
+		t.Fatalf("states = %s, %s, want running and cancelled", jobs[0].State, jobs[1].State)
+	}
+	m.mu.Lock()
+	running := m.running
+	m.mu.Unlock()
+	if running != 1 {
+		t.Fatalf("running = %d, want only the blocked job", running)
+	}
+	if history, _ := repo.ListUploadHistoryByStatus(models.UploadCancelled, 10); len(history) != 1 {
+		t.Fatalf("%d cancelled records, want 1", len(history))
+	}
+
+	unlock()
+	select {
+	case results := <-firstDone:
+		if results[0].Err != nil {
+			t.Fatal(results[0].Err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("first job did not finish after the lock was released")
+	}
+	if images, _ := repo.ListImages(ds.ID); len(images) != 1 {
+		t.Fatalf("%d images, want only the first file", len(images))
+	}
+}
@@ -0,0 +1,197 @@
+package ingest
+
+import (
+	"dataset-sync/database"
+	"dataset-sync/models"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// journal 将任务写入导入任务日志，写入失败时任务照常执行，只是无法在重启后继续
+func (m *Manager) journal(jobs []*Job) {
+	items := make([]*models.QueueItem, 0, len(jobs))
+	for _, job := range jobs {
+		var historyID int64
+		if job.item.history != nil {
+			historyID = job.item.history.ID
+		}
+		var metadata string
+		if !job.item.Meta.empty() {
+			data, _ := json.Marshal(job.item.Meta)
+			metadata = string(data)
+		}
+		items = append(items, &models.QueueItem{
+			DatasetID:  job.dataset.ID,
+			Path:       job.item.Path,
+			Name:       job.item.Name,
+			SubDir:     job.item.SubDir,
+			Label:      job.item.Label,
+			Origin:     job.item.Origin,
+			Source:     job.item.Source,
+			URL:        job.url,
+			Duplicates: string(job.opts.Duplicates),
+			TempRoot:   job.item.TempRoot,
+			HistoryID:  historyID,
+			Metadata:   metadata,
+		})
+	}
+	if err := m.in.repo.AddQueueItems(items); err != nil {
+		fmt.Println("写入导入任务日志失败:", err)
+		return
+	}
+	for i, job := range jobs {
+		job.item.journalID = items[i].ID
+	}
+}
+
+// Restore 继续上次运行时未完成的导入任务，返回重新加入队列的任务数
+// 需要在启动时、提交新任务之前调用：
+//   - 已记录保存路径且已有图片记录引用的任务视为已入库，直接删除日志
+//   - 已记录保存路径但没有入库的，删除残留的占位文件或未完成的文件后重新导入
+//   - 引用已有文件的重复图片没有保存路径，重新导入时数据集中已有同内容的引用记录则不再重复写入
+//   - 缓存目录中上次遗留的暂存、下载文件，以及不再被任务引用的解压目录一并清理
+//
+// done 在每组恢复的任务全部结束后回调，可以为空
+func (m *Manager) Restore(done func([]Result)) (int, error) {
+	items, err := m.in.repo.ListQueueItems()
+	if err != nil {
+		return 0, err
+	}
+	m.in.cleanTmpDir(items)
+
+	type group struct {
+		ds    *models.Dataset
+		opts  Options
+		jobs  []*Job
+		roots map[string]bool
+	}
+	var (
+		groups   []*group
+		datasets = make(map[int]*models.Dataset)
+		restored int
+	)
+	for _, item := range items {
+		if committed, err := m.in.recoverTarget(item); err != nil {
+			fmt.Println("检查导入任务失败:", err)
+			continue
+		} else if committed {
+			m.in.repo.DeleteQueueItem(item.ID)
+			continue
+		}
+
+		ds, ok := datasets[item.DatasetID]
+		if !ok {
+			if ds, err = m.in.repo.GetDataset(item.DatasetID); errors.Is(err, database.ErrNotFound) {
+				m.in.repo.DeleteQueueItem(item.ID)
+				continue
+			} else if err != nil {
+				return restored, err
+			}
+			datasets[item.DatasetID] = ds
+		}
+
+		// 同一数据集、同一重复处理方式的任务作为一组
+		opts := Options{Duplicates: DuplicatePolicy(item.Duplicates)}
+		var g *group
+		for _, candidate := range groups {
+			if candidate.ds.ID == ds.ID && candidate.opts == opts {
+				g = candidate
+			}
+		}
+		if g == nil {
+			g = &group{ds: ds, opts: opts, roots: make(map[string]bool)}
+			groups = append(groups, g)
+		}
+		g.jobs = append(g.jobs, &Job{
+			dataset: ds,
+			item: Item{
+				Path:      item.Path,
+				Name:      item.Name,
+				SubDir:    item.SubDir,
+				Label:     item.Label,
+				Origin:    item.Origin,
+				Source:    item.Source,
+				TempRoot:  item.TempRoot,
+				journalID: item.ID,
+				recovered: true,
+			},
+			url:  item.URL,
+			opts: opts,
+		})
+		if item.Metadata != "" {
+			job := g.jobs[len(g.jobs)-1]
+			if err := json.Unmarshal([]byte(item.Metadata), &job.item.Meta); err != nil {
+				fmt.Println("读取导入任务元数据失败:", err)
+			}
+		}
+		if item.HistoryID != 0 {
+			// 重试任务继续更新原有的上传记录
+			if record, err := m.in.repo.GetUploadHistory(item.HistoryID); err == nil {
+				job := g.jobs[len(g.jobs)-1]
+				job.item.history = record
+			}
+		}
+		if item.TempRoot != "" {
+			g.roots[item.TempRoot] = true
+		}
+		restored++
+	}
+
+	for _, g := range groups {
+		roots := g.roots
+		m.enqueue(g.jobs, func(results []Result) {
+			// 原来负责清理的界面已经不存在，由恢复的任务组删除解压目录
+			for root := range roots {
+				os.RemoveAll(root)
+			}
+			if done != nil {
+				done(results)
+			}
+		})
+	}
+	if restored > 0 {
+		fmt.Printf("恢复 %d 个未完成的导入任务\n", restored)
+	}
+	return restored, nil
+}
+
+// recoverTarget 检查任务上次占用的保存路径：已被图片记录引用时返回 true，否则删除残留文件
+func (in *Ingester) recoverTarget(item *models.QueueItem) (bool, error) {
+	if item.TargetPath == "" {
+		return false, nil
+	}
+	inUse, err := in.repo.ImagePathInUse(item.TargetPath)
+	if err != nil || inUse {
+		return inUse, err
+	}
+	for _, path := range []string{item.TargetPath, item.TargetPath + ".part"} {
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return false, fmt.Errorf("删除未完成的文件失败: %w", err)
+		}
+	}
+	return false, nil
+}
+
+// cleanTmpDir 清理缓存目录中上次运行遗留的暂存、下载文件和不再被任务引用的解压目录、剪贴板图片
+func (in *Ingester) cleanTmpDir(items []*models.QueueItem) {
+	tmp := in.tmpDir()
+	os.RemoveAll(filepath.Join(tmp, "staging"))
+	os.RemoveAll(filepath.Join(tmp, "downloads"))
+
+	inUse := make(map[string]bool)
+	for _, item := range items {
+		if item.TempRoot != "" {
+			inUse[filepath.Clean(item.TempRoot)] = true
+		}
+	}
+	archives, _ := filepath.Glob(filepath.Join(tmp, "archives", "*"))
+	clipboard, _ := filepath.Glob(filepath.Join(tmp, "clipboard", "*"))
+	for _, dir := range append(archives, clipboard...) {
+		if !inUse[filepath.Clean(dir)] {
+			os.RemoveAll(dir)
+		}
+	}
+}
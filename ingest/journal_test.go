@@ -0,0 +1,108 @@
+package ingest
+
+import (
+	"context"
+	"dataset-sync/models"
+	"os"
+	"testing"
+	"time"
+)
+
+// restoreAndWait 恢复任务日志并等待恢复的任务全部结束，返回恢复的任务数
+func restoreAndWait(t *testing.T, m *Manager) int {
+	t.Helper()
+	done := make(chan []Result, 1)
+	restored, err := m.Restore(func(r []Result) { done <- r })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if restored > 0 {
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			t.Fatal("restored jobs did not finish")
+		}
+	}
+	return restored
+}
+
+func TestRestoreSkipsCommittedJob(t *testing.T) {
+	repo, in := newTestIngester(t)
+	ds, _ := in.EnsureDataset("cats")
+	source := writeTestPNG(t, t.TempDir(), "a.png", 1)
+
+	// 上次运行已移动文件并写入图片记录，只是没来得及删除日志
+	dir, _ := in.datasetDir(ds, "")
+	target := writeTestPNG(t, dir, "a.png", 1)
+	repo.AddImage(&models.Image{DatasetID: ds.ID, FileName: "a.png", Path: target, SHA256: "sha", Size: 1})
+	repo.AddQueueItems([]*models.QueueItem{{DatasetID: ds.ID, Path: source, Name: "a.png", Origin: source, Source: models.SourceFile, TargetPath: target}})
+
+	if restored := restoreAndWait(t, NewManager(in, 1)); restored != 0 {
+		t.Fatalf("restored %d jobs, want the committed job dropped", restored)
+	}
+	if items, _ := repo.ListQueueItems(); len(items) != 0 {
+		t.Fatalf("%d journal entries left", len(items))
+	}
+	if images, _ := repo.ListImages(ds.ID); len(images) != 1 {
+		t.Fatalf("%d images, want the committed job not imported again", len(images))
+	}
+	if _, err := os.Stat(target); err != nil {
+		t.Fatal("committed file was removed")
+	}
+}
+
+func TestRestoreReimportsMovedFileWithoutRecord(t *testing.T) {
+	repo, in := newTestIngester(t)
+	ds, _ := in.EnsureDataset("cats")
+	source := writeTestPNG(t, t.TempDir(), "a.png", 1)
+
+	// 上次运行已把文件移动到保存路径，但在写入图片记录前退出
+	dir, _ := in.datasetDir(ds, "")
+	target := writeTestPNG(t, dir, "a.png", 1)
+	os.WriteFile(target+".part", []byte("partial"), 0o644)
+	repo.AddQueueItems([]*models.QueueItem{{DatasetID: ds.ID, Path: source, Name: "a.png", Origin: source, Source: models.SourceFile, TargetPath: target}})
+
+	if restored := restoreAndWait(t, NewManager(in, 1)); restored != 1 {
+		t.Fatalf("restored %d jobs, want 1", restored)
+	}
+	images, _ := repo.ListImages(ds.ID)
+	if len(images) != 1 || images[0].Path != target {
+		t.Fatalf("images = %+v, want one image saved at %s", images, target)
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Fatalf("dataset dir has %d files, want the leftovers replaced by the imported file", len(entries))
+	}
+	if items, _ := repo.ListQueueItems(); len(items) != 0 {
+		t.Fatalf("%d journal entries left", len(items))
+	}
+}
+
+func TestRestoreDoesNotRelinkDuplicate(t *testing.T) {
+	repo, in := newTestIngester(t)
+	cats, _ := in.EnsureDataset("cats")
+	dogs, _ := in.EnsureDataset("dogs")
+	src := t.TempDir()
+	first := in.ingest(context.Background(), cats, FileItem(writeTestPNG(t, src, "a.png", 1), models.SourceFile), Options{}, nil, nil)
+	if first.Err != nil {
+		t.Fatal(first.Err)
+	}
+
+	// 引用已有文件的任务没有保存路径：上次已写入引用记录，重启后不应再引用一次
+	source := writeTestPNG(t, src, "b.png", 1)
+	linked := in.ingest(context.Background(), dogs, FileItem(source, models.SourceFile), Options{Duplicates: DuplicateLink}, nil, nil)
+	if linked.Image == nil {
+		t.Fatalf("link import = %+v", linked)
+	}
+	repo.AddQueueItems([]*models.QueueItem{{DatasetID: dogs.ID, Path: source, Name: "b.png", Origin: source, Source: models.SourceFile, Duplicates: string(DuplicateLink)}})
+
+	if restored := restoreAndWait(t, NewManager(in, 1)); restored != 1 {
+		t.Fatalf("restored %d jobs, want 1", restored)
+	}
+	if images, _ := repo.ListImages(dogs.ID); len(images) != 1 || images[0].Path != first.Image.Path {
+		t.Fatalf("images = %+v, want the single existing link", images)
+	}
+	if _, err := os.Stat(source); err != nil {
+		t.Fatal("source file was removed")
+	}
+}
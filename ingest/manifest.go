@@ -0,0 +1,308 @@
+package ingest
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// manifestExts 支持的清单文件扩展名：CSV（第一行为表头）和 JSONL（每行一个 JSON 对象）
+var manifestExts = map[string]bool{".csv": true, ".jsonl": true, ".ndjson": true}
+
+// 猜测列映射时使用的常见列名（小写）
+var (
+	fileColumns       = []string{"file", "filename", "file_name", "path", "image", "name", "文件", "文件名", "路径"}
+	labelColumns      = []string{"label", "class", "category", "tag", "标签", "类别"}
+	provenanceColumns = []string{"source", "provenance", "url", "author", "credit", "出处", "来源", "作者"}
+	licenseColumns    = []string{"license", "licence", "许可证", "授权"}
+)
+
+// Metadata 清单文件为图片提供的附加信息
+type Metadata struct {
+	Provenance string            `json:"provenance,omitempty"` // 出处，如作者、原始链接
+	License    string            `json:"license,omitempty"`    // 许可证
+	Attributes map[string]string `json:"attributes,omitempty"` // 未映射到固定字段的其他列
+}
+
+// empty 是否没有任何信息
+func (m Metadata) empty() bool {
+	return m.Provenance == "" && m.License == "" && len(m.Attributes) == 0
+}
+
+// attributesJSON 自由属性编码为 JSON 对象，没有时为空字符串
+func (m Metadata) attributesJSON() string {
+	if len(m.Attributes) == 0 {
+		return ""
+	}
+	data, _ := json.Marshal(m.Attributes)
+	return string(data)
+}
+
+// IsManifest 是否为支持的清单文件
+func IsManifest(name string) bool {
+	return manifestExts[strings.ToLower(filepath.Ext(name))]
+}
+
+// FindManifests 返回目录第一层中的清单文件，用于导入文件夹或压缩包时自动识别
+func FindManifests(dir string) []string {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	var manifests []string
+	for _, entry := range entries {
+		if entry.Type().IsRegular() && !strings.HasPrefix(entry.Name(), ".") && IsManifest(entry.Name()) {
+			manifests = append(manifests, filepath.Join(dir, entry.Name()))
+		}
+	}
+	return manifests
+}
+
+// Manifest 解析后的清单文件
+type Manifest struct {
+	Path    string
+	Columns []string            // 列名，CSV 按表头顺序，JSONL 按首次出现的顺序
+	Rows    []map[string]string // 每行各列的值
+	Lines   []int               // 每行在文件中的行号，用于报告
+}
+
+// ReadManifest 读取 CSV 或 JSONL 清单文件
+func ReadManifest(path string) (*Manifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取清单文件失败: %w", err)
+	}
+	data = bytes.TrimPrefix(data, []byte("\ufeff")) // Excel 导出的 CSV 带 BOM
+
+	m := &Manifest{Path: path}
+	if strings.ToLower(filepath.Ext(path)) == ".csv" {
+		err = m.readCSV(bytes.NewReader(data))
+	} else {
+		err = m.readJSONL(bytes.NewReader(data))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("解析清单文件 %s 失败: %w", filepath.Base(path), err)
+	}
+	if len(m.Rows) == 0 {
+		return nil, fmt.Errorf("清单文件 %s 中没有数据", filepath.Base(path))
+	}
+	return m, nil
+}
+
+// readCSV 第一行为表头，空列名按位置命名，各行列数可以不同
+func (m *Manifest) readCSV(r io.Reader) error {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if err != nil {
+		return err
+	}
+	for i, name := range header {
+		if name = strings.TrimSpace(name); name == "" {
+			name = fmt.Sprintf("列%d", i+1)
+		}
+		m.Columns = append(m.Columns, name)
+	}
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		line, _ := reader.FieldPos(0)
+		row := make(map[string]string, len(record))
+		for i, value := range record {
+			if i < len(m.Columns) {
+				row[m.Columns[i]] = strings.TrimSpace(value)
+			}
+		}
+		m.Rows = append(m.Rows, row)
+		m.Lines = append(m.Lines, line)
+	}
+}
+
+// readJSONL 每行一个 JSON 对象，空行忽略；非字符串的值按 JSON 文本保存
+func (m *Manifest) readJSONL(r io.Reader) error {
+	seen := make(map[string]bool)
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 4<<20)
+	for line := 1; scanner.Scan(); line++ {
+		text := bytes.TrimSpace(scanner.Bytes())
+		if len(text) == 0 {
+			continue
+		}
+		var object map[string]any
+		if err := json.Unmarshal(text, &object); err != nil {
+			return fmt.Errorf("第 %d 行: %w", line, err)
+		}
+		row := make(map[string]string, len(object))
+		for key, value := range object {
+			switch v := value.(type) {
+			case nil:
+				row[key] = ""
+			case string:
+				row[key] = strings.TrimSpace(v)
+			default:
+				data, _ := json.Marshal(v)
+				row[key] = string(data)
+			}
+		}
+		// JSON 对象的键没有顺序，新出现的列按名称排序后追加
+		var added []string
+		for key := range object {
+			if !seen[key] {
+				seen[key] = true
+				added = append(added, key)
+			}
+		}
+		sort.Strings(added)
+		m.Columns = append(m.Columns, added...)
+		m.Rows = append(m.Rows, row)
+		m.Lines = append(m.Lines, line)
+	}
+	return scanner.Err()
+}
+
+// ManifestMapping 清单列与图片字段的对应关系，列名为空表示不使用
+type ManifestMapping struct {
+	File       string // 文件名或相对路径所在的列，必填
+	Label      string // 类别标签，非空时覆盖按文件夹得到的标签
+	Provenance string // 出处
+	License    string // 许可证
+	Attributes bool   // 其余的列是否作为自由属性保存
+}
+
+// GuessMapping 按常见列名猜测列映射
+func (m *Manifest) GuessMapping() ManifestMapping {
+	find := func(candidates []string) string {
+		for _, candidate := range candidates {
+			for _, column := range m.Columns {
+				if strings.EqualFold(column, candidate) {
+					return column
+				}
+			}
+		}
+		return ""
+	}
+	mapping := ManifestMapping{
+		File:       find(fileColumns),
+		Label:      find(labelColumns),
+		Provenance: find(provenanceColumns),
+		License:    find(licenseColumns),
+		Attributes: true,
+	}
+	if mapping.File == "" && len(m.Columns) > 0 {
+		mapping.File = m.Columns[0]
+	}
+	return mapping
+}
+
+// ManifestReport 清单与待导入文件的匹配结果
+type ManifestReport struct {
+	Matched       int      // 匹配到文件的行数
+	UnmatchedRows []string // 没有对应文件的行，如“第 3 行: cat.jpg”
+	MissingFiles  []string // 清单中没有对应行的文件（相对路径）
+}
+
+// Apply 按映射把清单中的字段写入匹配的导入项，返回匹配结果
+// 先按相对路径匹配（也接受完整路径），找不到时按文件名匹配（文件名在待导入文件中唯一时）；
+// 同一文件出现在多行时只使用第一行，其余行记为没有对应文件
+func (m *Manifest) Apply(items []Item, mapping ManifestMapping) (ManifestReport, error) {
+	var report ManifestReport
+	if mapping.File == "" {
+		return report, errors.New("请选择文件名所在的列")
+	}
+
+	byPath := make(map[string]int, len(items))
+	byName := make(map[string]int, len(items))
+	for i, item := range items {
+		byPath[itemRelPath(item)] = i
+		if item.Path != "" {
+			byPath[filepath.ToSlash(filepath.Clean(item.Path))] = i
+		}
+		if _, ok := byName[item.Name]; ok {
+			byName[item.Name] = -1 // 文件名不唯一，不能只按文件名匹配
+		} else {
+			byName[item.Name] = i
+		}
+	}
+
+	used := make([]bool, len(items))
+	for r, row := range m.Rows {
+		key := manifestKey(row[mapping.File])
+		index, ok := byPath[key]
+		if !ok {
+			if index, ok = byName[path.Base(key)]; ok && index < 0 {
+				ok = false
+			}
+		}
+		if key == "" || !ok || used[index] {
+			report.UnmatchedRows = append(report.UnmatchedRows, fmt.Sprintf("第 %d 行: %s", m.Lines[r], row[mapping.File]))
+			continue
+		}
+		used[index] = true
+		report.Matched++
+		items[index].applyRow(row, mapping, m.Columns)
+	}
+	for i, item := range items {
+		if !used[i] {
+			report.MissingFiles = append(report.MissingFiles, itemRelPath(item))
+		}
+	}
+	return report, nil
+}
+
+// applyRow 将清单中一行的字段写入导入项
+func (item *Item) applyRow(row map[string]string, mapping ManifestMapping, columns []string) {
+	if label := row[mapping.Label]; mapping.Label != "" && label != "" {
+		item.Label = label
+	}
+	meta := Metadata{}
+	if mapping.Provenance != "" {
+		meta.Provenance = row[mapping.Provenance]
+	}
+	if mapping.License != "" {
+		meta.License = row[mapping.License]
+	}
+	if mapping.Attributes {
+		for _, column := range columns {
+			switch column {
+			case mapping.File, mapping.Label, mapping.Provenance, mapping.License:
+				continue
+			}
+			if value := row[column]; value != "" {
+				if meta.Attributes == nil {
+					meta.Attributes = make(map[string]string)
+				}
+				meta.Attributes[column] = value
+			}
+		}
+	}
+	item.Meta = meta
+}
+
+// itemRelPath 导入项在数据集中的相对路径，使用 / 分隔
+func itemRelPath(item Item) string {
+	return path.Join(filepath.ToSlash(item.SubDir), item.Name)
+}
+
+// manifestKey 统一清单中文件路径的写法：使用 / 分隔，去掉开头的 ./
+func manifestKey(value string) string {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return ""
+	}
+	return path.Clean(strings.ReplaceAll(value, `\`, "/"))
+}
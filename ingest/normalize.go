@@ -0,0 +1,86 @@
+package ingest
+
+import (
+	"dataset-sync/models"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"strings"
+)
+
+// 数据集可选的归一化目标格式
+const (
+	NormalizeNone = ""     // 保留原格式和原文件
+	NormalizeJPEG = "jpeg" // 统一转换为 JPEG
+	NormalizePNG  = "png"  // 统一转换为 PNG（无损，不使用质量参数）
+)
+
+// DefaultJPEGQuality 未设置质量时转换 JPEG 使用的质量
+const DefaultJPEGQuality = 90
+
+// encodeNormalized 按目标格式重新编码图片
+// 重新编码只写入像素数据，EXIF（包括 GPS）、ICC、注释等元数据都不会保留
+func encodeNormalized(path string, img image.Image, format string, quality int) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("创建文件失败: %w", err)
+	}
+
+	switch format {
+	case NormalizeJPEG:
+		if quality <= 0 || quality > 100 {
+			quality = DefaultJPEGQuality
+		}
+		err = jpeg.Encode(f, flattenAlpha(img), &jpeg.Options{Quality: quality})
+	case NormalizePNG:
+		err = png.Encode(f, img)
+	default:
+		err = fmt.Errorf("不支持的目标格式: %s", format)
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(path)
+		return fmt.Errorf("转换图片格式失败: %w", err)
+	}
+	return nil
+}
+
+// flattenAlpha JPEG 不支持透明通道，透明区域合成到白色背景上
+func flattenAlpha(img image.Image) image.Image {
+	if opaque, ok := img.(interface{ Opaque() bool }); ok && opaque.Opaque() {
+		return img
+	}
+	bounds := img.Bounds()
+	dst := image.NewRGBA(bounds)
+	draw.Draw(dst, bounds, image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.Draw(dst, bounds, img, bounds.Min, draw.Over)
+	return dst
+}
+
+// normalize 按数据集设置转换暂存文件：应用 EXIF 方向、转换格式并去除元数据
+// 返回转换后的文件路径和图片，img 的大小、SHA-256、尺寸和格式更新为转换后的值，OriginalSHA256 保持不变
+func normalize(ds *models.Dataset, staged string, img *models.Image, decoded image.Image) (string, image.Image, error) {
+	format := strings.ToLower(ds.NormalizeFormat)
+	oriented := applyOrientation(decoded, readOrientation(staged, img.Format))
+
+	out := staged + ".normalized" + formatExt(format)
+	if err := encodeNormalized(out, oriented, format, ds.NormalizeQuality); err != nil {
+		return "", nil, err
+	}
+	normalized, err := DescribeImage(out, img.Source)
+	if err != nil {
+		os.Remove(out)
+		return "", nil, err
+	}
+	img.Size = normalized.Size
+	img.SHA256 = normalized.SHA256
+	img.Width, img.Height = normalized.Width, normalized.Height
+	img.Format, img.MIMEType = normalized.Format, normalized.MIMEType
+	return out, oriented, nil
+}
@@ -0,0 +1,79 @@
+package ingest
+
+import (
+	"dataset-sync/models"
+	"dataset-sync/utils"
+	"errors"
+	"fmt"
+)
+
+// QuotaError 一批文件会超出数据集容量上限或使磁盘剩余空间低于设置值，整批拒绝导入
+type QuotaError struct {
+	Reason string
+}
+
+func (e *QuotaError) Error() string {
+	return e.Reason
+}
+
+// quotaf 构造容量检查失败错误
+func quotaf(format string, args ...any) error {
+	return &QuotaError{Reason: fmt.Sprintf(format, args...)}
+}
+
+// IsQuotaExceeded 判断错误是否为容量检查不通过
+func IsQuotaExceeded(err error) bool {
+	var quotaErr *QuotaError
+	return errors.As(err, &quotaErr)
+}
+
+// BatchSize 一批待导入文件的规模，用于导入前检查
+type BatchSize struct {
+	Count   int   // 文件数
+	Bytes   int64 // 本地文件总大小，链接下载的大小未知，不计入
+	Staging int64 // 同时暂存在缓存目录中的最大大小（单个文件最大大小 × 并发数，不超过总大小）
+}
+
+// spaceCheck 需要检查剩余空间的目录
+type spaceCheck struct {
+	label string
+	path  string
+	need  int64 // 本批文件在该目录中需要占用的空间
+}
+
+// CheckQuota 导入前检查：数据集的图片数量和总大小上限，以及保存目录、缓存目录所在磁盘的最小剩余空间
+// ds 的图片数量和总大小需为最新统计；内容重复而被跳过的文件也按会入库计算
+func (in *Ingester) CheckQuota(ds *models.Dataset, batch BatchSize) error {
+	if ds.MaxImages > 0 && ds.ImageCount+batch.Count > ds.MaxImages {
+		return quotaf("数据集 %s 最多 %d 张图片，已有 %d 张，再导入 %d 张会超出上限",
+			ds.Name, ds.MaxImages, ds.ImageCount, batch.Count)
+	}
+	if ds.MaxSize > 0 && ds.TotalSize+batch.Bytes > ds.MaxSize {
+		return quotaf("数据集 %s 最多 %s，已有 %s，再导入 %s 会超出上限",
+			ds.Name, utils.FormatSize(ds.MaxSize), utils.FormatSize(ds.TotalSize), utils.FormatSize(batch.Bytes))
+	}
+	if in.cfg == nil || in.cfg.MinFreeSpace <= 0 {
+		return nil
+	}
+
+	// 缓存目录只暂存正在导入的文件，存放目录需要容纳全部文件
+	dirs := []spaceCheck{{"缓存目录", in.tmpDir(), batch.Staging}}
+	if in.cfg.SaveDir != "" {
+		dirs = append(dirs, spaceCheck{"存放目录", in.cfg.SaveDir, batch.Bytes})
+	}
+	for _, dir := range dirs {
+		free, err := utils.DiskFree(dir.path)
+		if errors.Is(err, utils.ErrDiskFreeUnsupported) {
+			return nil
+		}
+		if err != nil {
+			fmt.Printf("检查%s剩余空间失败: %v\n", dir.label, err)
+			continue
+		}
+		if int64(free)-dir.need < in.cfg.MinFreeSpace {
+			return quotaf("%s %s 所在磁盘剩余 %s，导入 %s 后将低于设置的最小剩余空间 %s",
+				dir.label, dir.path, utils.FormatSize(int64(free)), utils.FormatSize(dir.need), utils.FormatSize(in.cfg.MinFreeSpace))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,212 @@
+package ingest
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultRenameTemplate 开启自动重命名但未设置模板时使用
+const DefaultRenameTemplate = "{dataset}_{seq:06}{ext}"
+
+// 自动重命名模板支持的变量，格式为 {变量} 或 {变量:参数}
+const (
+	renameDataset = "dataset" // 数据集名称
+	renameName    = "name"    // 原始文件名（不含扩展名）
+	renameExt     = "ext"     // 扩展名（含点，小写），原文件没有扩展名时按图片格式补全
+	renameSeq     = "seq"     // 数据集内递增序号，参数为补零宽度，如 {seq:06}
+	renameDate    = "date"    // 入库时间，参数为 Go 时间格式，默认 20060102
+	renameHash    = "hash"    // SHA-256 前缀，参数为长度，默认 8
+	renameLabel   = "label"   // 类别标签
+	renameFormat  = "format"  // 图片格式，如 jpeg、png
+	renameWidth   = "width"   // 宽度（像素）
+	renameHeight  = "height"  // 高度（像素）
+)
+
+// RenameVariables 模板变量说明，用于设置界面提示
+var RenameVariables = []string{
+	"{dataset} 数据集名称",
+	"{name} 原文件名",
+	"{ext} 扩展名",
+	"{seq:06} 序号（补零宽度）",
+	"{date:20060102} 日期（Go 时间格式）",
+	"{hash:8} SHA-256 前缀",
+	"{label} 标签",
+	"{format} 图片格式",
+	"{width} / {height} 尺寸",
+}
+
+// RenameVars 渲染模板所需的数据
+type RenameVars struct {
+	Dataset string
+	Name    string // 不含扩展名
+	Ext     string // 含点
+	Seq     int64
+	Time    time.Time
+	SHA256  string
+	Label   string
+	Format  string
+	Width   int
+	Height  int
+}
+
+// renamePart 模板的一段：字面文本或变量
+type renamePart struct {
+	text  string // 字面文本，field 为空时有效
+	field string
+	arg   string
+}
+
+// RenameTemplate 解析后的重命名模板
+type RenameTemplate struct {
+	parts  []renamePart
+	hasExt bool
+	hasSeq bool
+}
+
+// ParseRenameTemplate 解析重命名模板，遇到未知变量或非法参数时返回错误
+func ParseRenameTemplate(template string) (*RenameTemplate, error) {
+	if strings.TrimSpace(template) == "" {
+		return nil, errors.New("重命名模板为空")
+	}
+
+	t := new(RenameTemplate)
+	rest := template
+	for rest != "" {
+		open := strings.IndexAny(rest, "{}")
+		if open < 0 {
+			t.parts = append(t.parts, renamePart{text: rest})
+			break
+		}
+		if rest[open] == '}' {
+			return nil, fmt.Errorf("重命名模板中有多余的 }: %s", template)
+		}
+		if open > 0 {
+			t.parts = append(t.parts, renamePart{text: rest[:open]})
+		}
+		end := strings.IndexByte(rest[open:], '}')
+		if end < 0 {
+			return nil, fmt.Errorf("重命名模板中的 { 没有闭合: %s", template)
+		}
+		field, arg, _ := strings.Cut(rest[open+1:open+end], ":")
+		part := renamePart{field: strings.TrimSpace(field), arg: arg}
+		if err := validatePart(part); err != nil {
+			return nil, err
+		}
+		t.hasExt = t.hasExt || part.field == renameExt
+		t.hasSeq = t.hasSeq || part.field == renameSeq
+		t.parts = append(t.parts, part)
+		rest = rest[open+end+1:]
+	}
+	return t, nil
+}
+
+// validatePart 检查变量名和参数
+func validatePart(part renamePart) error {
+	switch part.field {
+	case renameDataset, renameName, renameExt, renameLabel, renameFormat, renameWidth, renameHeight:
+		if part.arg != "" {
+			return fmt.Errorf("变量 {%s} 不支持参数", part.field)
+		}
+	case renameSeq:
+		if part.arg != "" {
+			if width, err := strconv.Atoi(part.arg); err != nil || width < 0 || width > 20 {
+				return fmt.Errorf("序号宽度不合法: %s", part.arg)
+			}
+		}
+	case renameHash:
+		if part.arg != "" {
+			if n, err := strconv.Atoi(part.arg); err != nil || n < 1 || n > 64 {
+				return fmt.Errorf("哈希长度应为 1-64: %s", part.arg)
+			}
+		}
+	case renameDate:
+	default:
+		return fmt.Errorf("未知的模板变量: {%s}", part.field)
+	}
+	return nil
+}
+
+// UsesSequence 模板中是否包含序号，包含时每次渲染前需要取新的序号
+func (t *RenameTemplate) UsesSequence() bool {
+	return t.hasSeq
+}
+
+// Render 生成文件名，模板没有 {ext} 时自动追加扩展名，结果中的非法字符会被替换
+func (t *RenameTemplate) Render(vars RenameVars) string {
+	var b strings.Builder
+	for _, part := range t.parts {
+		if part.field == "" {
+			b.WriteString(part.text)
+			continue
+		}
+		b.WriteString(renderPart(part, vars))
+	}
+	if !t.hasExt {
+		b.WriteString(vars.Ext)
+	}
+	return safeName(b.String())
+}
+
+// renderPart 渲染单个变量
+func renderPart(part renamePart, vars RenameVars) string {
+	switch part.field {
+	case renameDataset:
+		return vars.Dataset
+	case renameName:
+		return vars.Name
+	case renameExt:
+		return vars.Ext
+	case renameSeq:
+		width, _ := strconv.Atoi(part.arg)
+		return fmt.Sprintf("%0*d", width, vars.Seq)
+	case renameDate:
+		layout := part.arg
+		if layout == "" {
+			layout = "20060102"
+		}
+		return vars.Time.Format(layout)
+	case renameHash:
+		n := 8
+		if part.arg != "" {
+			n, _ = strconv.Atoi(part.arg)
+		}
+		return vars.SHA256[:min(n, len(vars.SHA256))]
+	case renameLabel:
+		return vars.Label
+	case renameFormat:
+		return vars.Format
+	case renameWidth:
+		return strconv.Itoa(vars.Width)
+	case renameHeight:
+		return strconv.Itoa(vars.Height)
+	}
+	return ""
+}
+
+// SampleRenameVars 设置界面预览使用的示例数据
+func SampleRenameVars() RenameVars {
+	return RenameVars{
+		Dataset: "示例数据集",
+		Name:    "IMG_0001",
+		Ext:     ".jpg",
+		Seq:     42,
+		Time:    time.Now(),
+		SHA256:  "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08",
+		Label:   "cat",
+		Format:  "jpeg",
+		Width:   1920,
+		Height:  1080,
+	}
+}
+
+// renameExtension 文件的扩展名（小写），没有扩展名时按图片格式补全
+func renameExtension(name, format string) string {
+	if ext := strings.ToLower(filepath.Ext(name)); ext != "" {
+		return ext
+	}
+	return formatExt(format)
+}
@@ -0,0 +1,111 @@
+package ingest
+
+import (
+	"dataset-sync/models"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"strings"
+)
+
+// SupportedExts 支持导入的图片扩展名
+var SupportedExts = map[string]bool{
+	".jpg":  true,
+	".jpeg": true,
+	".png":  true,
+	".gif":  true,
+	".bmp":  true,
+	".tif":  true,
+	".tiff": true,
+	".webp": true,
+}
+
+// IsSupported 判断文件扩展名是否为支持的图片格式
+func IsSupported(name string) bool {
+	return SupportedExts[strings.ToLower(filepath.Ext(name))]
+}
+
+// ScanFile 目录扫描得到的图片文件
+type ScanFile struct {
+	Path    string // 完整路径
+	RelPath string // 相对扫描根目录的路径
+	Size    int64  // 文件大小
+}
+
+// ScanResult 目录预扫描结果，用于导入前展示汇总
+type ScanResult struct {
+	Root      string             // 扫描根目录
+	Source    models.ImageSource // 图片来源
+	Archive   string             // 来源压缩包路径，目录扫描时为空
+	Files     []ScanFile         // 支持的图片文件
+	TotalSize int64              // 图片总大小
+	Skipped   int                // 跳过的非图片文件数量
+}
+
+// ScanDir 递归遍历目录，只保留支持的图片文件，跳过隐藏文件和目录
+func ScanDir(root string) (*ScanResult, error) {
+	result := &ScanResult{Root: root, Source: models.SourceFile}
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path != root && strings.HasPrefix(d.Name(), ".") {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() || !d.Type().IsRegular() {
+			return nil
+		}
+		if !IsSupported(d.Name()) {
+			result.Skipped++
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		result.Files = append(result.Files, ScanFile{Path: path, RelPath: rel, Size: info.Size()})
+		result.TotalSize += info.Size()
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("扫描目录失败: %w", err)
+	}
+	return result, nil
+}
+
+// Items 将扫描结果转换为导入项，保留相对子目录
+// labelFromFolder 为 true 时，使用第一级子文件夹名作为类别标签
+func (r *ScanResult) Items(labelFromFolder bool) []Item {
+	items := make([]Item, 0, len(r.Files))
+	for _, f := range r.Files {
+		subDir := filepath.Dir(f.RelPath)
+		if subDir == "." {
+			subDir = ""
+		}
+		item := Item{
+			Path:   f.Path,
+			Name:   filepath.Base(f.Path),
+			SubDir: subDir,
+			Origin: f.Path,
+			Source: r.Source,
+		}
+		if r.Archive != "" {
+			// 解压出的文件是临时文件，记录压缩包内的路径
+			item.Origin = r.Archive + "!/" + filepath.ToSlash(f.RelPath)
+			item.TempRoot = r.Root
+		}
+		if labelFromFolder && subDir != "" {
+			item.Label, _, _ = strings.Cut(filepath.ToSlash(subDir), "/")
+		}
+		items = append(items, item)
+	}
+	return items
+}
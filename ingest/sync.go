@@ -0,0 +1,123 @@
+package ingest
+
+import (
+	"bytes"
+	"context"
+	"dataset-sync/models"
+	"dataset-sync/storage"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// SyncManifestName 同步时一并推送的清单文件，位于数据集根目录，每行一张图片，可作为清单重新导入
+	SyncManifestName = "manifest.jsonl"
+	// SyncLinkedDir 引用其他数据集文件的重复图片在存储后端中的目录，文件名前加 SHA-256 前 8 位，避免与本数据集的文件重名
+	SyncLinkedDir = "linked"
+)
+
+// syncManifestLine 清单文件中的一行
+type syncManifestLine struct {
+	File       string          `json:"file"` // 图片在数据集中的相对路径
+	Label      string          `json:"label,omitempty"`
+	SHA256     string          `json:"sha256"`
+	Size       int64           `json:"size"`
+	Width      int             `json:"width"`
+	Height     int             `json:"height"`
+	Format     string          `json:"format"`
+	Provenance string          `json:"provenance,omitempty"`
+	License    string          `json:"license,omitempty"`
+	Attributes json.RawMessage `json:"attributes,omitempty"`
+}
+
+// SyncDataset 把数据集的图片和清单文件推送到数据集设置的存储后端，全部成功且期间数据集没有变化时标记为已同步
+// 键为图片在数据集文件夹中的相对路径；指向其他数据集文件的重复图片放在 SyncLinkedDir 下，同一文件只推送一次
+func (in *Ingester) SyncDataset(ctx context.Context, ds *models.Dataset, progress func(done, total int)) (storage.SyncReport, error) {
+	// 重新读取，revision 与后面列出的图片对应
+	current, err := in.repo.GetDataset(ds.ID)
+	if err != nil {
+		return storage.SyncReport{}, err
+	}
+	revision := current.Revision
+	backend, err := storage.New(current.StorageBackend, current.StorageConfig)
+	if err != nil {
+		return storage.SyncReport{}, err
+	}
+	files, err := in.syncFiles(current)
+	if err != nil {
+		return storage.SyncReport{}, err
+	}
+
+	report, err := storage.Sync(ctx, backend, files, progress)
+	if err != nil {
+		return report, fmt.Errorf("同步数据集 %s 失败: %w", ds.Name, err)
+	}
+	if len(report.Failed) == 0 {
+		// 同步期间导入的图片不在本次推送的文件中，此时 revision 已变化，保持未同步
+		synced, err := in.repo.MarkDatasetSynced(ds.ID, revision)
+		if err != nil {
+			return report, err
+		}
+		if synced {
+			ds.Status = 1
+		} else {
+			fmt.Printf("数据集 %s 在同步期间有变化，需要再次同步\n", ds.Name)
+		}
+	}
+	return report, nil
+}
+
+// syncFiles 数据集中需要同步的文件，有图片时最后一个为清单文件
+func (in *Ingester) syncFiles(ds *models.Dataset) ([]storage.File, error) {
+	images, err := in.repo.ListImages(ds.ID)
+	if err != nil {
+		return nil, err
+	}
+	var root string
+	if in.cfg != nil && in.cfg.SaveDir != "" {
+		root = filepath.Join(in.cfg.SaveDir, safeName(ds.Name))
+	}
+
+	files := make([]storage.File, 0, len(images)+1)
+	seen := make(map[string]bool, len(images))
+	keys := make(map[string]string, len(images)) // 键 -> 本地路径
+	var manifest bytes.Buffer
+	encoder := json.NewEncoder(&manifest)
+	encoder.SetEscapeHTML(false)
+	for _, img := range images {
+		if seen[img.Path] {
+			continue
+		}
+		seen[img.Path] = true
+		key := SyncLinkedDir + "/" + img.SHA256[:min(8, len(img.SHA256))] + "_" + img.FileName
+		if rel, err := filepath.Rel(root, img.Path); root != "" && err == nil && !strings.HasPrefix(rel, "..") {
+			key = filepath.ToSlash(rel)
+		}
+		if key == SyncManifestName {
+			return nil, fmt.Errorf("图片 %s 与同步的清单文件重名", img.Path)
+		}
+		if other, ok := keys[key]; ok {
+			return nil, fmt.Errorf("%s 与 %s 在存储后端中的键相同: %s", img.Path, other, key)
+		}
+		keys[key] = img.Path
+		files = append(files, storage.File{Key: key, Path: img.Path, Size: img.Size})
+
+		line := syncManifestLine{
+			File: key, Label: img.Label, SHA256: img.SHA256, Size: img.Size,
+			Width: img.Width, Height: img.Height, Format: img.Format,
+			Provenance: img.Provenance, License: img.License,
+		}
+		if json.Valid([]byte(img.Attributes)) {
+			line.Attributes = json.RawMessage(img.Attributes)
+		}
+		if err := encoder.Encode(line); err != nil {
+			return nil, fmt.Errorf("生成清单文件失败: %w", err)
+		}
+	}
+	if manifest.Len() > 0 {
+		files = append(files, storage.File{Key: SyncManifestName, Size: int64(manifest.Len()), Content: manifest.Bytes()})
+	}
+	return files, nil
+}
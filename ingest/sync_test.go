@@ -0,0 +1,90 @@
+package ingest
+
+import (
+	"context"
+	"dataset-sync/conf"
+	"dataset-sync/database"
+	"dataset-sync/models"
+	"dataset-sync/storage"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSyncFilesKeysLinkedImagesSeparately(t *testing.T) {
+	repo := database.NewMemoryRepository()
+	saveDir := t.TempDir()
+	in := New(repo, &conf.DatasetConfig{SaveDir: saveDir})
+	ds, err := in.EnsureDataset("cats")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	own := filepath.Join(saveDir, "cats", "a.png")
+	other := filepath.Join(saveDir, "dogs", "a.png") // 引用其他数据集的同名文件
+	for _, img := range []*models.Image{
+		{DatasetID: ds.ID, FileName: "a.png", Path: own, SHA256: "11111111aaaa", Size: 1},
+		{DatasetID: ds.ID, FileName: "a.png", Path: other, SHA256: "22222222bbbb", Size: 2},
+	} {
+		if err := repo.AddImage(img); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	files, err := in.syncFiles(ds)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var keys []string
+	for _, file := range files {
+		keys = append(keys, file.Key)
+	}
+	want := []string{"a.png", SyncLinkedDir + "/22222222_a.png", SyncManifestName}
+	if strings.Join(keys, ",") != strings.Join(want, ",") {
+		t.Fatalf("keys = %v, want %v", keys, want)
+	}
+	if manifest := string(files[2].Content); !strings.Contains(manifest, `"file":"`+want[1]+`"`) {
+		t.Fatalf("manifest does not list the linked key:\n%s", manifest)
+	}
+}
+
+func TestSyncDatasetKeepsChangesDuringSync(t *testing.T) {
+	repo := database.NewMemoryRepository()
+	in := New(repo, &conf.DatasetConfig{SaveDir: t.TempDir()})
+	ds, err := in.EnsureDataset("cats")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ds.StorageBackend = storage.BackendLocal
+	ds.StorageConfig, _ = storage.EncodeConfig(storage.LocalConfig{Root: t.TempDir()})
+	if err := repo.UpdateDatasetSettings(ds); err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "a.png")
+	os.WriteFile(path, []byte("a"), 0o644)
+	repo.AddImage(&models.Image{DatasetID: ds.ID, FileName: "a.png", Path: path, SHA256: "11111111", Size: 1})
+
+	// 第一次推送时模拟导入了新图片：进度回调中标记数据集有变化
+	changed := false
+	_, err = in.SyncDataset(context.Background(), ds, func(done, total int) {
+		if !changed {
+			changed = true
+			repo.MarkDatasetChanged(ds.ID, "")
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := repo.GetDataset(ds.ID); got.Status != 0 {
+		t.Fatal("dataset changed during the sync was marked as synced")
+	}
+
+	if _, err := in.SyncDataset(context.Background(), ds, nil); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := repo.GetDataset(ds.ID); got.Status != 1 {
+		t.Fatal("dataset was not marked as synced")
+	}
+}
@@ -0,0 +1,88 @@
+package ingest
+
+import (
+	"dataset-sync/conf"
+	"dataset-sync/models"
+	"dataset-sync/utils"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ValidationError 图片不符合入库规则，Error() 即展示给用户的具体原因
+type ValidationError struct {
+	Reason string
+}
+
+func (e *ValidationError) Error() string {
+	return e.Reason
+}
+
+// rejectf 构造校验失败错误
+func rejectf(format string, args ...any) error {
+	return &ValidationError{Reason: fmt.Sprintf(format, args...)}
+}
+
+// IsRejected 判断错误是否为校验不通过
+func IsRejected(err error) bool {
+	var validationErr *ValidationError
+	return errors.As(err, &validationErr)
+}
+
+// normalizeFormat 统一格式名称，配置中允许写 jpg、tif
+func normalizeFormat(format string) string {
+	switch format = strings.ToLower(strings.TrimSpace(format)); format {
+	case "jpg":
+		return "jpeg"
+	case "tif":
+		return "tiff"
+	}
+	return format
+}
+
+// Validate 按规则检查图片的格式、尺寸、大小和宽高比，规则为空时直接通过
+// 只使用 DescribeImage 得到的头信息，图片是否完整由后续的完整解码检查
+func Validate(img *models.Image, rules *conf.ValidationConfig) error {
+	if rules == nil {
+		return nil
+	}
+
+	if len(rules.Formats) > 0 {
+		allowed := false
+		names := make([]string, 0, len(rules.Formats))
+		for _, format := range rules.Formats {
+			name := normalizeFormat(format)
+			names = append(names, name)
+			allowed = allowed || name == img.Format
+		}
+		if !allowed {
+			return rejectf("格式 %s 不在允许范围内（%s）", img.Format, strings.Join(names, "、"))
+		}
+	}
+
+	if rules.MaxFileSize > 0 && img.Size > rules.MaxFileSize {
+		return rejectf("文件大小 %s 超过上限 %s", utils.FormatSize(img.Size), utils.FormatSize(rules.MaxFileSize))
+	}
+
+	switch {
+	case rules.MinWidth > 0 && img.Width < rules.MinWidth:
+		return rejectf("宽度 %d 小于最小宽度 %d", img.Width, rules.MinWidth)
+	case rules.MaxWidth > 0 && img.Width > rules.MaxWidth:
+		return rejectf("宽度 %d 超过最大宽度 %d", img.Width, rules.MaxWidth)
+	case rules.MinHeight > 0 && img.Height < rules.MinHeight:
+		return rejectf("高度 %d 小于最小高度 %d", img.Height, rules.MinHeight)
+	case rules.MaxHeight > 0 && img.Height > rules.MaxHeight:
+		return rejectf("高度 %d 超过最大高度 %d", img.Height, rules.MaxHeight)
+	}
+
+	if (rules.MinAspectRatio > 0 || rules.MaxAspectRatio > 0) && img.Height > 0 {
+		ratio := float64(img.Width) / float64(img.Height)
+		if rules.MinAspectRatio > 0 && ratio < rules.MinAspectRatio {
+			return rejectf("宽高比 %.2f 小于最小值 %.2f", ratio, rules.MinAspectRatio)
+		}
+		if rules.MaxAspectRatio > 0 && ratio > rules.MaxAspectRatio {
+			return rejectf("宽高比 %.2f 超过最大值 %.2f", ratio, rules.MaxAspectRatio)
+		}
+	}
+	return nil
+}
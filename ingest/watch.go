@@ -0,0 +1,390 @@
+package ingest
+
+import (
+	"dataset-sync/database"
+	"dataset-sync/models"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+const (
+	watchStableDuration = 2 * time.Second        // 文件大小和修改时间保持不变多久后视为写入完成
+	watchPollInterval   = 500 * time.Millisecond // 检查待导入文件是否稳定的间隔
+)
+
+// Watcher 监控数据集的监控文件夹，新保存的图片写入完成后提交到任务队列自动导入
+// 只监控文件夹本身，不包含子文件夹
+type Watcher struct {
+	jobs *Manager
+	repo database.DatasetRepository
+
+	// OnImported 每批自动导入结束后回调，可以为空
+	OnImported func(results []Result)
+
+	mu       sync.Mutex
+	fsw      *fsnotify.Watcher
+	folders  map[string]*models.WatchFolder // 已监控的目录 -> 设置
+	pending  map[string]*pendingFile        // 等待写入完成的文件
+	inFlight map[string]bool                // 已提交、尚未导入结束的文件
+	stop     chan struct{}
+}
+
+// pendingFile 等待写入完成的文件
+type pendingFile struct {
+	folder      *models.WatchFolder
+	size        int64
+	modTime     time.Time
+	stableSince time.Time
+}
+
+// NewWatcher 创建监控器，自动导入的文件提交到 jobs
+func NewWatcher(jobs *Manager) *Watcher {
+	return &Watcher{
+		jobs:     jobs,
+		repo:     jobs.in.repo,
+		folders:  make(map[string]*models.WatchFolder),
+		pending:  make(map[string]*pendingFile),
+		inFlight: make(map[string]bool),
+	}
+}
+
+// Start 开始监控全部启用的文件夹
+func (w *Watcher) Start() error {
+	fsw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("创建文件监控失败: %w", err)
+	}
+	w.mu.Lock()
+	w.fsw = fsw
+	w.stop = make(chan struct{})
+	w.mu.Unlock()
+	go w.loop(fsw, w.stop)
+	return w.Reload()
+}
+
+// Close 停止监控
+func (w *Watcher) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.fsw == nil {
+		return nil
+	}
+	close(w.stop)
+	err := w.fsw.Close()
+	w.fsw = nil
+	return err
+}
+
+// Reload 重新读取监控文件夹设置，新增或重新启用的文件夹会先扫描一次已有文件
+func (w *Watcher) Reload() error {
+	folders, err := w.repo.ListWatchFolders()
+	if err != nil {
+		return err
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.fsw == nil {
+		return errors.New("文件监控未启动")
+	}
+
+	wanted := make(map[string]*models.WatchFolder)
+	for _, folder := range folders {
+		if folder.Enabled {
+			wanted[filepath.Clean(folder.Path)] = folder
+		}
+	}
+	for dir := range w.folders {
+		if wanted[dir] == nil {
+			w.fsw.Remove(dir)
+			delete(w.folders, dir)
+		}
+	}
+
+	var errs []error
+	for dir, folder := range wanted {
+		if w.folders[dir] == nil {
+			if err := w.CheckPath(dir); err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			if err := w.fsw.Add(dir); err != nil {
+				errs = append(errs, fmt.Errorf("监控文件夹 %s 失败: %w", dir, err))
+				continue
+			}
+			w.folders[dir] = folder
+			w.scan(folder)
+			fmt.Println("开始监控文件夹:", dir)
+		} else {
+			*w.folders[dir] = *folder // 更新扩展名、处理方式等设置
+		}
+	}
+	// 待导入文件引用的设置可能已删除或修改
+	for path, file := range w.pending {
+		if folder := w.folders[filepath.Dir(path)]; folder == nil || !AcceptsFile(folder, path) {
+			delete(w.pending, path)
+		} else {
+			file.folder = folder
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// CheckPath 检查监控文件夹的路径：不能是存放目录或缩略图缓存目录，也不能位于其中
+// 否则入库的文件会再次触发导入，移动模式下还会把唯一的副本当作重复的源文件删除
+func (w *Watcher) CheckPath(path string) error {
+	in := w.jobs.in
+	dirs := []struct{ label, path string }{{"缩略图缓存目录", in.Thumbnails().Dir}}
+	if in.cfg != nil && in.cfg.SaveDir != "" {
+		dirs = append(dirs, struct{ label, path string }{"存放目录", in.cfg.SaveDir})
+	}
+	for _, dir := range dirs {
+		if isWithin(dir.path, path) {
+			return fmt.Errorf("监控文件夹 %s 位于%s %s 中", path, dir.label, dir.path)
+		}
+	}
+	return nil
+}
+
+// isWithin path 是否与 dir 相同或位于其中
+func isWithin(dir, path string) bool {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return false
+	}
+	path, err = filepath.Abs(path)
+	if err != nil {
+		return false
+	}
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// scan 把文件夹中已有的文件加入待导入列表，调用方需持有锁
+// 移动模式下已导入的文件已被删除，剩下的都需要导入；复制模式只导入上次之后修改过的文件
+func (w *Watcher) scan(folder *models.WatchFolder) {
+	entries, err := os.ReadDir(folder.Path)
+	if err != nil {
+		fmt.Println("扫描监控文件夹失败:", err)
+		return
+	}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		if folder.Mode == models.WatchMove || info.ModTime().After(folder.LastScan) {
+			w.track(filepath.Join(filepath.Clean(folder.Path), entry.Name()))
+		}
+	}
+}
+
+// loop 处理文件系统事件，并定时检查待导入的文件
+func (w *Watcher) loop(fsw *fsnotify.Watcher, stop chan struct{}) {
+	ticker := time.NewTicker(watchPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case event, ok := <-fsw.Events:
+			if !ok {
+				return
+			}
+			if event.Has(fsnotify.Create) || event.Has(fsnotify.Write) {
+				w.mu.Lock()
+				w.track(event.Name)
+				w.mu.Unlock()
+			}
+		case err, ok := <-fsw.Errors:
+			if !ok {
+				return
+			}
+			fmt.Println("文件监控出错:", err)
+		case <-ticker.C:
+			w.checkPending()
+		case <-stop:
+			return
+		}
+	}
+}
+
+// track 记录新出现或被修改的文件，调用方需持有锁
+func (w *Watcher) track(path string) {
+	folder := w.folders[filepath.Dir(path)]
+	if folder == nil || w.inFlight[path] || !AcceptsFile(folder, path) {
+		return
+	}
+	info, err := os.Stat(path)
+	if err != nil || !info.Mode().IsRegular() {
+		return
+	}
+	if file := w.pending[path]; file != nil && file.size == info.Size() && file.modTime.Equal(info.ModTime()) {
+		return
+	}
+	w.pending[path] = &pendingFile{folder: folder, size: info.Size(), modTime: info.ModTime(), stableSince: time.Now()}
+}
+
+// checkPending 大小和修改时间在 watchStableDuration 内没有变化的文件视为写入完成，按文件夹分批提交导入
+func (w *Watcher) checkPending() {
+	now := time.Now()
+	ready := make(map[*models.WatchFolder][]*pendingFile)
+	paths := make(map[*pendingFile]string)
+
+	w.mu.Lock()
+	for path, file := range w.pending {
+		info, err := os.Stat(path)
+		if err != nil {
+			delete(w.pending, path) // 文件已被删除或移走
+			continue
+		}
+		if info.Size() != file.size || !info.ModTime().Equal(file.modTime) {
+			file.size, file.modTime, file.stableSince = info.Size(), info.ModTime(), now
+			continue
+		}
+		if info.Size() == 0 || now.Sub(file.stableSince) < watchStableDuration {
+			continue
+		}
+		delete(w.pending, path)
+		w.inFlight[path] = true
+		ready[file.folder] = append(ready[file.folder], file)
+		paths[file] = path
+	}
+	w.mu.Unlock()
+
+	for folder, files := range ready {
+		items := make([]Item, 0, len(files))
+		modTimes := make(map[string]time.Time, len(files))
+		for _, file := range files {
+			items = append(items, FileItem(paths[file], models.SourceWatch))
+			modTimes[paths[file]] = file.modTime
+		}
+		w.submit(folder, items, modTimes)
+	}
+}
+
+// submit 提交一批文件，导入结束后记录已处理文件的最新修改时间供复制模式重启后使用
+// 超出数据集容量上限等原因被拒绝时不更新记录，文件留在文件夹中，下次扫描时重新导入
+func (w *Watcher) submit(folder *models.WatchFolder, items []Item, modTimes map[string]time.Time) {
+	release := func() {
+		w.mu.Lock()
+		for _, item := range items {
+			delete(w.inFlight, item.Path)
+		}
+		w.mu.Unlock()
+	}
+	ds, err := w.repo.GetDataset(folder.DatasetID)
+	if err != nil {
+		fmt.Printf("监控文件夹 %s 的数据集不可用: %v\n", folder.Path, err)
+		release()
+		return
+	}
+
+	w.mu.Lock()
+	mode := folder.Mode
+	w.mu.Unlock()
+	err = w.jobs.SubmitItems(ds, items, Options{Duplicates: DuplicateSkip}, func(results []Result) {
+		// 移动模式：导入成功或内容重复的源文件删除，失败的保留以便重试
+		if mode == models.WatchMove {
+			for _, r := range results {
+				if r.Err == nil && (r.Image != nil || isOtherCopy(r.Existing, r.Source)) {
+					if err := os.Remove(r.Source); err != nil && !errors.Is(err, os.ErrNotExist) {
+						fmt.Println("删除源文件失败:", err)
+					}
+				}
+			}
+		}
+		w.updateLastScan(folder, results, modTimes)
+		release()
+		if w.OnImported != nil {
+			w.OnImported(results)
+		}
+	})
+	if err != nil {
+		fmt.Printf("监控文件夹 %s: %d 个文件未导入: %v\n", folder.Path, len(items), err)
+		release()
+		return
+	}
+	fmt.Printf("监控文件夹 %s: 自动导入 %d 个文件到 %s\n", folder.Path, len(items), ds.Name)
+}
+
+// isOtherCopy 已有图片的文件存在且与源文件不是同一个文件，此时删除源文件不会丢失数据
+func isOtherCopy(existing, source string) bool {
+	if existing == "" {
+		return false
+	}
+	existingInfo, err := os.Stat(existing)
+	if err != nil {
+		return false
+	}
+	sourceInfo, err := os.Stat(source)
+	return err == nil && !os.SameFile(existingInfo, sourceInfo)
+}
+
+// updateLastScan 把最后扫描时间推进到导入成功或内容重复的文件的最新修改时间，
+// 但不超过失败或取消的文件中最早的修改时间，保证这些文件在重启后仍会被重新扫描
+func (w *Watcher) updateLastScan(folder *models.WatchFolder, results []Result, modTimes map[string]time.Time) {
+	var latest, earliestFailed time.Time
+	for _, r := range results {
+		if modTime := modTimes[r.Source]; r.Err != nil && (earliestFailed.IsZero() || modTime.Before(earliestFailed)) {
+			earliestFailed = modTime
+		}
+	}
+	for _, r := range results {
+		modTime := modTimes[r.Source]
+		if r.Err == nil && modTime.After(latest) && (earliestFailed.IsZero() || modTime.Before(earliestFailed)) {
+			latest = modTime
+		}
+	}
+
+	w.mu.Lock()
+	if !latest.After(folder.LastScan) {
+		w.mu.Unlock()
+		return
+	}
+	folder.LastScan = latest
+	updated := *folder
+	w.mu.Unlock()
+	if err := w.repo.UpdateWatchFolder(&updated); err != nil {
+		fmt.Println("更新监控文件夹失败:", err)
+	}
+}
+
+// AcceptsFile 文件是否符合监控文件夹的扩展名规则，未设置时接受全部支持的图片格式
+// 以 . 或 ~ 开头的临时文件始终忽略
+func AcceptsFile(folder *models.WatchFolder, path string) bool {
+	name := filepath.Base(path)
+	if strings.HasPrefix(name, ".") || strings.HasPrefix(name, "~") || !IsSupported(name) {
+		return false
+	}
+	extensions := ParseExtensions(folder.Extensions)
+	if len(extensions) == 0 {
+		return true
+	}
+	ext := strings.TrimPrefix(strings.ToLower(filepath.Ext(name)), ".")
+	for _, allowed := range extensions {
+		if ext == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseExtensions 解析逗号或空格分隔的扩展名列表，统一为不带点的小写形式
+func ParseExtensions(text string) []string {
+	var extensions []string
+	for _, field := range strings.FieldsFunc(text, func(r rune) bool { return r == ',' || r == '，' || r == ' ' || r == ';' }) {
+		if ext := strings.TrimPrefix(strings.ToLower(strings.TrimSpace(field)), "."); ext != "" {
+			extensions = append(extensions, ext)
+		}
+	}
+	return extensions
+}
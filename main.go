@@ -2,6 +2,7 @@ package main
 
 import (
 	"dataset-sync/conf"
+	"dataset-sync/database"
 	"dataset-sync/ui"
 	"fmt"
 	"fyne.io/fyne/v2/app"
@@ -18,11 +19,24 @@ func main() {
 		return
 	}
 	fmt.Println("读取配置文件成功")
+	// 连接数据库
+	repo, err := database.Open(conf.Conf.DatabaseConfig, conf.Conf.MySQLConfig)
+	if err != nil {
+		fmt.Printf("连接数据库失败, err:%v\n", err)
+		return
+	}
+	defer repo.Close()
+	// 升级数据库结构
+	if err := repo.Migrate(); err != nil {
+		fmt.Printf("数据库迁移失败, err:%v\n", err)
+		return
+	}
+	fmt.Println("连接数据库成功")
 	// 启动
 	fmt.Println("启动软件..。。..")
 	// 显示主界面
 	a := app.NewWithID("com.github.wmgr-demo")
 	w := a.NewWindow("图片数据集管理工具")
-	ui.NewMainUI(w)
+	ui.NewMainUI(w, repo)
 	w.ShowAndRun()
 }
@@ -5,12 +5,24 @@ import "time"
 
 // Dataset 表示一个数据集
 type Dataset struct {
-	ID          int       `json:"id"`          // 数据集 ID，主键
-	Name        string    `json:"name"`        // 数据集名称
-	Description string    `json:"description"` // 数据集描述
-	ImageCount  int       `json:"image_count"` // 图片数量
-	CreatedAt   time.Time `json:"created_at"`  // 创建时间
-	UpdatedAt   time.Time `json:"updated_at"`  // 更新时间
-	Status      int       `json:"status"`      // 数据集状态 0: 更新后未同步 1: 更新后已同步
-	Cover       string    `json:"cover"`       // 添加封面字段
+	ID             int       `json:"id"`              // 数据集 ID，主键
+	Name           string    `json:"name"`            // 数据集名称
+	Description    string    `json:"description"`     // 数据集描述
+	ImageCount     int       `json:"image_count"`     // 图片数量，由 images 表统计得到
+	TotalSize      int64     `json:"total_size"`      // 图片总大小（字节），由 images 表统计得到
+	DuplicateCount int       `json:"duplicate_count"` // 上传时检测到的重复图片数量，由上传记录统计得到
+	CreatedAt      time.Time `json:"created_at"`      // 创建时间
+	UpdatedAt      time.Time `json:"updated_at"`      // 更新时间
+	Status         int       `json:"status"`          // 数据集状态 0: 更新后未同步 1: 更新后已同步
+	Cover          string    `json:"cover"`           // 封面图片的 SHA-256，为空时显示默认图标
+	Revision       int64     `json:"revision"`        // 内容或同步目标变化的次数，同步结束时据此判断期间是否有变化
+
+	NormalizeFormat  string `json:"normalize_format"`  // 入库时统一转换的格式 jpeg / png，为空表示保留原格式
+	NormalizeQuality int    `json:"normalize_quality"` // 转换为 JPEG 时的质量 1-100，0 使用默认值
+
+	MaxImages int   `json:"max_images"` // 图片数量上限，0 表示不限制
+	MaxSize   int64 `json:"max_size"`   // 图片总大小上限（字节），0 表示不限制
+
+	StorageBackend string `json:"storage_backend"` // 同步到的存储后端类型，如 local，为空表示不同步
+	StorageConfig  string `json:"storage_config"`  // 存储后端设置（JSON 对象），内容由后端类型决定
 }
@@ -0,0 +1,41 @@
+package models
+
+import "time"
+
+// ImageSource 图片来源
+type ImageSource string
+
+const (
+	SourceFile      ImageSource = "file"      // 拖放或选择的本地文件
+	SourceURL       ImageSource = "url"       // 网络链接下载
+	SourceArchive   ImageSource = "archive"   // 压缩包解压
+	SourceWatch     ImageSource = "watch"     // 监控文件夹自动导入
+	SourceClipboard ImageSource = "clipboard" // 剪贴板粘贴的图片
+)
+
+// Image 表示数据集中的一张图片
+type Image struct {
+	ID             int64       `json:"id"`              // 图片 ID，主键
+	DatasetID      int         `json:"dataset_id"`      // 所属数据集 ID
+	FileName       string      `json:"file_name"`       // 文件名
+	OriginalName   string      `json:"original_name"`   // 入库前的原始文件名，自动重命名时与 FileName 不同
+	Path           string      `json:"path"`            // 存储路径
+	Size           int64       `json:"size"`            // 文件大小（字节）
+	SHA256         string      `json:"sha256"`          // 文件内容 SHA-256（十六进制），归一化时为转换后的文件
+	OriginalSHA256 string      `json:"original_sha256"` // 入库前原始文件的 SHA-256，未归一化时与 SHA256 相同
+	Width          int         `json:"width"`           // 宽度（像素）
+	Height         int         `json:"height"`          // 高度（像素）
+	Format         string      `json:"format"`          // 解码得到的格式，如 jpeg、png
+	MIMEType       string      `json:"mime_type"`       // MIME 类型，如 image/jpeg
+	Source         ImageSource `json:"source"`          // 图片来源
+	Label          string      `json:"label"`           // 类别标签，可由文件夹名或清单文件映射
+	Provenance     string      `json:"provenance"`      // 出处，如作者、原始链接，来自清单文件
+	License        string      `json:"license"`         // 许可证，来自清单文件
+	Attributes     string      `json:"attributes"`      // 清单文件中的自由属性（JSON 对象），没有时为空
+	DuplicateOf    int64       `json:"duplicate_of"`    // 重复时指向最早入库的同内容图片 ID，0 表示不重复
+	AHash          uint64      `json:"ahash"`           // 均值感知哈希
+	DHash          uint64      `json:"dhash"`           // 差值感知哈希
+	PHash          uint64      `json:"phash"`           // DCT 感知哈希
+	Hashed         bool        `json:"hashed"`          // 感知哈希是否已计算
+	CreatedAt      time.Time   `json:"created_at"`      // 入库时间
+}
@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// QueueItem 导入任务日志中的一条未完成任务，程序重启后据此继续导入
+type QueueItem struct {
+	ID         int64       `json:"id"`          // 任务 ID，主键
+	DatasetID  int         `json:"dataset_id"`  // 目标数据集 ID
+	Path       string      `json:"path"`        // 本地文件路径，链接任务为空
+	Name       string      `json:"name"`        // 入库使用的文件名
+	SubDir     string      `json:"sub_dir"`     // 数据集目录下的相对子目录
+	Label      string      `json:"label"`       // 类别标签
+	Origin     string      `json:"origin"`      // 原始路径或链接
+	Source     ImageSource `json:"source"`      // 图片来源
+	URL        string      `json:"url"`         // 需要下载的链接，本地文件为空
+	Duplicates string      `json:"duplicates"`  // 重复图片处理方式
+	TempRoot   string      `json:"temp_root"`   // 压缩包解压出的临时目录，全部导入后删除
+	TargetPath string      `json:"target_path"` // 已占用的保存路径，用于重启时判断是否已入库
+	HistoryID  int64       `json:"history_id"`  // 重试任务对应的上传记录 ID，新任务为 0
+	Metadata   string      `json:"metadata"`    // 清单文件提供的元数据（JSON），没有时为空
+	CreatedAt  time.Time   `json:"created_at"`  // 提交时间
+}
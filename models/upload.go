@@ -1,10 +1,57 @@
 package models
 
+// UploadStatus 上传记录状态，数据库中保存英文取值，界面通过 Label 显示中文
+type UploadStatus string
+
+const (
+	UploadQueued           UploadStatus = "queued"            // 排队中（重试等待中）
+	UploadRunning          UploadStatus = "running"           // 导入中
+	UploadSucceeded        UploadStatus = "succeeded"         // 导入成功
+	UploadFailed           UploadStatus = "failed"            // 导入失败，ErrorMessage 为具体原因
+	UploadSkippedDuplicate UploadStatus = "skipped_duplicate" // 内容重复，按策略跳过
+	UploadCancelled        UploadStatus = "cancelled"         // 用户取消
+)
+
+// Label 状态的中文名称
+func (s UploadStatus) Label() string {
+	switch s {
+	case UploadQueued:
+		return "排队中"
+	case UploadRunning:
+		return "导入中"
+	case UploadSucceeded:
+		return "成功"
+	case UploadFailed:
+		return "失败"
+	case UploadSkippedDuplicate:
+		return "重复"
+	case UploadCancelled:
+		return "已取消"
+	}
+	return string(s)
+}
+
+// Finished 是否为最终状态
+func (s UploadStatus) Finished() bool {
+	return s != UploadQueued && s != UploadRunning
+}
+
 type UploadDetails struct {
-	ImageName    string `json:"image_name"`
-	DatasetName  string `json:"dataset_name"`
-	ImagePath    string `json:"image_path"`
-	ImageSize    string `json:"image_size"`
-	UploadTime   string `json:"upload_time"`
-	UploadStatus string `json:"upload_status"`
+	ID           int64        `json:"id"`
+	DatasetID    int          `json:"dataset_id"` // 目标数据集 ID，重试时使用
+	ImageName    string       `json:"image_name"`
+	DatasetName  string       `json:"dataset_name"`
+	ImagePath    string       `json:"image_path"`
+	ImageSize    string       `json:"image_size"`
+	UploadTime   string       `json:"upload_time"`
+	UploadStatus UploadStatus `json:"upload_status"`
+	Duplicate    bool         `json:"duplicate"`     // 是否与已有图片内容重复
+	ErrorMessage string       `json:"error_message"` // 失败的具体原因
+	Attempts     int          `json:"attempts"`      // 已尝试次数，首次导入为 1
+	SourcePath   string       `json:"source_path"`   // 重试时使用的源文件路径或链接，为空表示无法重试（如压缩包解压出的临时文件）
+}
+
+// Retryable 失败或取消的记录，且仍知道源文件时可以重试
+func (r *UploadDetails) Retryable() bool {
+	return (r.UploadStatus == UploadFailed || r.UploadStatus == UploadCancelled) && r.SourcePath != ""
 }
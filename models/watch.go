@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// 监控文件夹的源文件处理方式
+const (
+	WatchCopy = "copy" // 导入后保留源文件
+	WatchMove = "move" // 导入成功后删除源文件
+)
+
+// WatchFolder 数据集的监控文件夹，保存到其中的图片在大小稳定后自动导入
+type WatchFolder struct {
+	ID         int       `json:"id"`         // 主键
+	DatasetID  int       `json:"dataset_id"` // 导入到的数据集
+	Path       string    `json:"path"`       // 监控的文件夹（不含子文件夹）
+	Extensions string    `json:"extensions"` // 接受的扩展名，逗号分隔，如 "jpg,png"，为空时接受全部支持的图片格式
+	Mode       string    `json:"mode"`       // 源文件处理方式 copy / move
+	Enabled    bool      `json:"enabled"`    // 是否启用
+	LastScan   time.Time `json:"last_scan"`  // 已提交导入的文件的最新修改时间，复制模式重启后只导入更新的文件
+	CreatedAt  time.Time `json:"created_at"` // 创建时间
+}
@@ -0,0 +1,192 @@
+// Package phash 实现感知哈希（aHash、dHash、pHash），用于发现缩放、重新编码后的近似重复图片
+package phash
+
+import (
+	"image"
+	"math"
+	"math/bits"
+	"sort"
+
+	"golang.org/x/image/draw"
+)
+
+// 支持的算法，对应配置项 dataset.phash_algorithm
+const (
+	AlgorithmAHash = "ahash" // 均值哈希，速度最快
+	AlgorithmDHash = "dhash" // 差值哈希，对亮度变化不敏感
+	AlgorithmPHash = "phash" // DCT 感知哈希，对缩放和压缩最稳定
+)
+
+// DefaultThreshold 默认的汉明距离阈值，距离不超过该值视为近似重复
+const DefaultThreshold = 10
+
+// Hashes 一张图片的三种感知哈希
+type Hashes struct {
+	AHash uint64
+	DHash uint64
+	PHash uint64
+}
+
+// Compute 计算图片的全部感知哈希
+func Compute(img image.Image) Hashes {
+	return Hashes{
+		AHash: AHash(img),
+		DHash: DHash(img),
+		PHash: PHash(img),
+	}
+}
+
+// Get 按算法名称取出对应的哈希，未知算法时使用 pHash
+func (h Hashes) Get(algorithm string) uint64 {
+	switch algorithm {
+	case AlgorithmAHash:
+		return h.AHash
+	case AlgorithmDHash:
+		return h.DHash
+	default:
+		return h.PHash
+	}
+}
+
+// Distance 两个哈希的汉明距离
+func Distance(a, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
+
+// grayscale 缩放为 w×h 的灰度图
+func grayscale(img image.Image, w, h int) *image.Gray {
+	gray := image.NewGray(image.Rect(0, 0, w, h))
+	draw.BiLinear.Scale(gray, gray.Bounds(), img, img.Bounds(), draw.Src, nil)
+	return gray
+}
+
+// AHash 均值哈希：缩放到 8×8 灰度，高于平均值的像素记为 1
+func AHash(img image.Image) uint64 {
+	gray := grayscale(img, 8, 8)
+	var sum int
+	for _, p := range gray.Pix {
+		sum += int(p)
+	}
+	mean := sum / len(gray.Pix)
+
+	var hash uint64
+	for i, p := range gray.Pix {
+		if int(p) > mean {
+			hash |= 1 << uint(i)
+		}
+	}
+	return hash
+}
+
+// DHash 差值哈希：缩放到 9×8 灰度，每行相邻像素左边小于右边时记为 1
+func DHash(img image.Image) uint64 {
+	gray := grayscale(img, 9, 8)
+	var hash uint64
+	bit := 0
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			if gray.GrayAt(x, y).Y < gray.GrayAt(x+1, y).Y {
+				hash |= 1 << uint(bit)
+			}
+			bit++
+		}
+	}
+	return hash
+}
+
+const dctSize = 32 // pHash 使用 32×32 的 DCT
+
+// dctTable 预先计算的 DCT 余弦系数
+var dctTable = func() [dctSize][dctSize]float64 {
+	var table [dctSize][dctSize]float64
+	for u := 0; u < dctSize; u++ {
+		for x := 0; x < dctSize; x++ {
+			table[u][x] = math.Cos(float64(2*x+1) * float64(u) * math.Pi / (2 * dctSize))
+		}
+	}
+	return table
+}()
+
+// PHash DCT 感知哈希：缩放到 32×32 灰度做二维 DCT，取左上角 8×8 低频系数与中位数比较
+func PHash(img image.Image) uint64 {
+	gray := grayscale(img, dctSize, dctSize)
+
+	// 先对行做一维 DCT，再对列做，只需要计算低频的 8 列 / 8 行
+	var rows [dctSize][8]float64
+	for y := 0; y < dctSize; y++ {
+		for u := 0; u < 8; u++ {
+			var sum float64
+			for x := 0; x < dctSize; x++ {
+				sum += float64(gray.Pix[y*gray.Stride+x]) * dctTable[u][x]
+			}
+			rows[y][u] = sum
+		}
+	}
+	var coeffs [64]float64
+	for v := 0; v < 8; v++ {
+		for u := 0; u < 8; u++ {
+			var sum float64
+			for y := 0; y < dctSize; y++ {
+				sum += rows[y][u] * dctTable[v][y]
+			}
+			coeffs[v*8+u] = sum
+		}
+	}
+
+	// 直流分量反映整体亮度，不参与中位数计算
+	sorted := make([]float64, 63)
+	copy(sorted, coeffs[1:])
+	sort.Float64s(sorted)
+	median := (sorted[31] + sorted[32]) / 2
+
+	var hash uint64
+	for i, c := range coeffs {
+		if c > median {
+			hash |= 1 << uint(i)
+		}
+	}
+	return hash
+}
+
+// Group 将汉明距离不超过 threshold 的哈希归为一组（传递闭包），只返回包含两个及以上成员的组
+// 返回值为输入切片的下标
+func Group(hashes []uint64, threshold int) [][]int {
+	parent := make([]int, len(hashes))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+
+	for i := range hashes {
+		for j := i + 1; j < len(hashes); j++ {
+			if Distance(hashes[i], hashes[j]) <= threshold {
+				if ri, rj := find(i), find(j); ri != rj {
+					parent[rj] = ri
+				}
+			}
+		}
+	}
+
+	byRoot := make(map[int][]int)
+	var roots []int
+	for i := range hashes {
+		root := find(i)
+		if _, ok := byRoot[root]; !ok {
+			roots = append(roots, root)
+		}
+		byRoot[root] = append(byRoot[root], i)
+	}
+	var groups [][]int
+	for _, root := range roots {
+		if len(byRoot[root]) > 1 {
+			groups = append(groups, byRoot[root])
+		}
+	}
+	return groups
+}
@@ -0,0 +1,523 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"dataset-sync/utils"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+)
+
+const (
+	DefaultGitHubAPI    = "https://api.github.com" // GitHub API 地址，GitHub Enterprise 为 https://主机/api/v3
+	DefaultGitHubBranch = "main"
+	GitHubMaxFileSize   = 100 << 20 // GitHub 拒绝超过 100 MB 的文件
+
+	githubBatchFiles    = 500 // 批量写入时每个提交最多包含的文件数
+	githubCommitRetries = 3   // 分支在提交期间被其他人更新时的重试次数
+	githubAPIVersion    = "2022-11-28"
+)
+
+// GitHubConfig GitHub 仓库后端的设置
+type GitHubConfig struct {
+	Repo    string `json:"repo"`               // 仓库，格式为 owner/name
+	Branch  string `json:"branch,omitempty"`   // 分支，为空时使用 main，不存在时在第一次提交时创建
+	Path    string `json:"path,omitempty"`     // 数据集在仓库中的目录，为空表示仓库根目录
+	Token   string `json:"token,omitempty"`    // 访问令牌，需要仓库内容的写权限；为空时读取环境变量 GITHUB_TOKEN
+	BaseURL string `json:"base_url,omitempty"` // API 地址，为空时使用 DefaultGitHubAPI
+}
+
+// ErrEmptyRepository 仓库中还没有任何提交，GitHub 的 git-data API 对空仓库返回 409
+var ErrEmptyRepository = errors.New("GitHub 仓库为空，请先在 GitHub 上创建初始提交（如添加 README）")
+
+// GitHubError GitHub API 返回的错误
+type GitHubError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *GitHubError) Error() string {
+	return fmt.Sprintf("GitHub API 错误 %d: %s", e.StatusCode, e.Message)
+}
+
+// githubStatus 错误是否为指定状态码的 GitHubError
+func githubStatus(err error, code int) bool {
+	var apiErr *GitHubError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == code
+}
+
+// GitHub 把对象保存为 GitHub 仓库某个分支中的文件，键对应设置的目录下的相对路径
+// 读取和删除通过 contents API；写入先逐个上传 blob，再用 git-data API 把一批文件放在同一个提交中
+type GitHub struct {
+	cfg    GitHubConfig
+	repo   string // API 中的仓库路径 /repos/owner/name
+	prefix string // 数据集在仓库中的目录，不含首尾的 /
+	client *http.Client
+}
+
+// NewGitHub 创建 GitHub 仓库后端，client 为空时使用 http.DefaultClient
+// BaseURL 和 client 可以指向 httptest 搭建的假 API，用于测试
+func NewGitHub(cfg GitHubConfig, client *http.Client) (*GitHub, error) {
+	owner, name, ok := strings.Cut(strings.Trim(strings.TrimSpace(cfg.Repo), "/"), "/")
+	if !ok || owner == "" || name == "" || strings.Contains(name, "/") {
+		return nil, fmt.Errorf("仓库格式应为 owner/name: %s", cfg.Repo)
+	}
+	if cfg.Branch = strings.TrimSpace(cfg.Branch); cfg.Branch == "" {
+		cfg.Branch = DefaultGitHubBranch
+	}
+	if cfg.Token = strings.TrimSpace(cfg.Token); cfg.Token == "" {
+		cfg.Token = os.Getenv("GITHUB_TOKEN")
+	}
+	if cfg.Token == "" {
+		return nil, errors.New("请设置 GitHub 访问令牌")
+	}
+	if cfg.BaseURL = strings.TrimRight(strings.TrimSpace(cfg.BaseURL), "/"); cfg.BaseURL == "" {
+		cfg.BaseURL = DefaultGitHubAPI
+	}
+	if u, err := url.Parse(cfg.BaseURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("无效的 GitHub API 地址: %s", cfg.BaseURL)
+	}
+
+	var prefix string
+	if strings.Trim(cfg.Path, "/ ") != "" {
+		cleaned, err := CleanKey(cfg.Path)
+		if err != nil {
+			return nil, fmt.Errorf("无效的仓库目录: %w", err)
+		}
+		prefix = cleaned
+	}
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &GitHub{
+		cfg:    cfg,
+		repo:   "/repos/" + url.PathEscape(owner) + "/" + url.PathEscape(name),
+		prefix: prefix,
+		client: client,
+	}, nil
+}
+
+// repoPath 键在仓库中的路径
+func (g *GitHub) repoPath(key string) (string, error) {
+	key, err := CleanKey(key)
+	if err != nil {
+		return "", err
+	}
+	return path.Join(g.prefix, key), nil
+}
+
+// contentsURL contents API 中文件的地址，路径逐段转义
+func (g *GitHub) contentsURL(repoPath string) string {
+	parts := strings.Split(repoPath, "/")
+	for i, part := range parts {
+		parts[i] = url.PathEscape(part)
+	}
+	return g.repo + "/contents/" + strings.Join(parts, "/")
+}
+
+// send 发送请求，状态码不是 2xx 时返回 GitHubError；调用方负责关闭响应
+// length 为请求体大小，未知时传 -1
+func (g *GitHub) send(ctx context.Context, method, endpoint string, body io.Reader, length int64, accept string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, g.cfg.BaseURL+endpoint, body)
+	if err != nil {
+		return nil, fmt.Errorf("创建 GitHub 请求失败: %w", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+		if length >= 0 {
+			req.ContentLength = length
+		}
+	}
+	req.Header.Set("Accept", accept)
+	req.Header.Set("Authorization", "Bearer "+g.cfg.Token)
+	req.Header.Set("X-GitHub-Api-Version", githubAPIVersion)
+
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("请求 GitHub 失败: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		defer resp.Body.Close()
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+		var payload struct {
+			Message string `json:"message"`
+		}
+		if json.Unmarshal(data, &payload) != nil || payload.Message == "" {
+			payload.Message = strings.TrimSpace(string(data))
+		}
+		if payload.Message == "" {
+			payload.Message = resp.Status
+		}
+		return nil, &GitHubError{StatusCode: resp.StatusCode, Message: payload.Message}
+	}
+	return resp, nil
+}
+
+// call 发送 JSON 请求（in 为空时不带请求体），响应解析到 out（可以为空）
+func (g *GitHub) call(ctx context.Context, method, endpoint string, in, out any) error {
+	var (
+		body   io.Reader
+		length int64 = -1
+	)
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return fmt.Errorf("编码 GitHub 请求失败: %w", err)
+		}
+		body, length = bytes.NewReader(data), int64(len(data))
+	}
+	return g.callStream(ctx, method, endpoint, body, length, out)
+}
+
+// callStream 发送已编码的请求体，响应解析到 out（可以为空）
+func (g *GitHub) callStream(ctx context.Context, method, endpoint string, body io.Reader, length int64, out any) error {
+	resp, err := g.send(ctx, method, endpoint, body, length, "application/vnd.github+json")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if out == nil {
+		io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("解析 GitHub 响应失败: %w", err)
+	}
+	return nil
+}
+
+// blobBody 上传 blob 的请求体，以流的方式对内容做 base64 编码，不把整个文件读入内存
+// 返回请求体和大小
+func blobBody(r io.Reader, size int64) (io.ReadCloser, int64) {
+	head := []byte(`{"encoding":"base64","content":"`)
+	const tail = `"}`
+
+	pr, pw := io.Pipe()
+	go func() {
+		_, err := pw.Write(head)
+		if err == nil {
+			encoder := base64.NewEncoder(base64.StdEncoding, pw)
+			if _, err = io.Copy(encoder, r); err == nil {
+				err = encoder.Close()
+			}
+		}
+		if err == nil {
+			_, err = io.WriteString(pw, tail)
+		}
+		pw.CloseWithError(err)
+	}()
+	return pr, int64(len(head)) + int64(base64.StdEncoding.EncodedLen(int(size))) + int64(len(tail))
+}
+
+// contentInfo contents API 返回的文件信息
+type contentInfo struct {
+	Type string `json:"type"`
+	Path string `json:"path"`
+	Size int64  `json:"size"`
+	SHA  string `json:"sha"`
+}
+
+// stat 读取文件信息，文件不存在或是目录时返回 ErrNotExist
+func (g *GitHub) stat(ctx context.Context, repoPath string) (contentInfo, error) {
+	var raw json.RawMessage
+	err := g.call(ctx, http.MethodGet, g.contentsURL(repoPath)+"?ref="+url.QueryEscape(g.cfg.Branch), nil, &raw)
+	if githubStatus(err, http.StatusNotFound) {
+		return contentInfo{}, ErrNotExist
+	}
+	if err != nil {
+		return contentInfo{}, err
+	}
+	var info contentInfo
+	if bytes.HasPrefix(bytes.TrimSpace(raw), []byte("[")) || json.Unmarshal(raw, &info) != nil || info.Type != "file" {
+		return contentInfo{}, ErrNotExist // 目录返回的是数组
+	}
+	return info, nil
+}
+
+// Put 通过 git-data API 写入单个文件，产生一个提交；分支不存在时创建
+// contents API 写入时需要先查询原文件的 SHA，且不能创建分支，因此只用于读取和删除
+func (g *GitHub) Put(ctx context.Context, key string, r io.Reader, size int64) error {
+	repoPath, err := g.repoPath(key)
+	if err != nil {
+		return err
+	}
+	if size > GitHubMaxFileSize {
+		return tooLarge(size)
+	}
+	if size < 0 {
+		// 上传 blob 时需要知道大小，先读入内存（不超过上限）
+		data, err := io.ReadAll(io.LimitReader(r, GitHubMaxFileSize+1))
+		if err != nil {
+			return fmt.Errorf("读取 %s 失败: %w", key, err)
+		}
+		if len(data) > GitHubMaxFileSize {
+			return tooLarge(int64(len(data)))
+		}
+		r, size = bytes.NewReader(data), int64(len(data))
+	}
+	sha, err := g.createBlob(ctx, r, size)
+	if err != nil {
+		return fmt.Errorf("上传 %s 失败: %w", key, err)
+	}
+	return g.commitTree(ctx, []treeEntry{newTreeEntry(repoPath, sha)}, "Dataset-Sync: 更新 "+repoPath)
+}
+
+// tooLarge 超过 GitHub 单个文件大小上限的错误
+func tooLarge(size int64) error {
+	return fmt.Errorf("文件大小 %s 超过 GitHub 单个文件上限 %s", utils.FormatSize(size), utils.FormatSize(GitHubMaxFileSize))
+}
+
+// Get 通过 contents API 读取文件原始内容（不超过 100 MB）
+func (g *GitHub) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	repoPath, err := g.repoPath(key)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := g.send(ctx, http.MethodGet, g.contentsURL(repoPath)+"?ref="+url.QueryEscape(g.cfg.Branch), nil, -1, "application/vnd.github.raw+json")
+	if githubStatus(err, http.StatusNotFound) {
+		return nil, ErrNotExist
+	}
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// Stat 获取文件大小和 blob SHA，GitHub 不提供修改时间
+func (g *GitHub) Stat(ctx context.Context, key string) (ObjectInfo, error) {
+	repoPath, err := g.repoPath(key)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	info, err := g.stat(ctx, repoPath)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	key, _ = CleanKey(key)
+	return ObjectInfo{Key: key, Size: info.Size, SHA: info.SHA}, nil
+}
+
+// List 读取分支最新提交的完整文件树，返回设置的目录下键以 prefix 开头的文件；分支不存在时为空
+func (g *GitHub) List(ctx context.Context, prefix string) ([]ObjectInfo, error) {
+	head, tree, err := g.head(ctx)
+	if err != nil || head == "" {
+		return nil, err
+	}
+	var result struct {
+		Tree []struct {
+			Path string `json:"path"`
+			Type string `json:"type"`
+			Size int64  `json:"size"`
+			SHA  string `json:"sha"`
+		} `json:"tree"`
+		Truncated bool `json:"truncated"`
+	}
+	if err := g.call(ctx, http.MethodGet, g.repo+"/git/trees/"+tree+"?recursive=1", nil, &result); err != nil {
+		return nil, err
+	}
+	if result.Truncated {
+		return nil, errors.New("仓库中的文件过多，GitHub 返回的文件树不完整，请为数据集设置单独的仓库")
+	}
+
+	prefix = strings.TrimPrefix(strings.ReplaceAll(prefix, `\`, "/"), "/")
+	var objects []ObjectInfo
+	for _, entry := range result.Tree {
+		if entry.Type != "blob" {
+			continue
+		}
+		key := entry.Path
+		if g.prefix != "" {
+			var ok bool
+			if key, ok = strings.CutPrefix(entry.Path, g.prefix+"/"); !ok {
+				continue
+			}
+		}
+		if strings.HasPrefix(key, prefix) {
+			objects = append(objects, ObjectInfo{Key: key, Size: entry.Size, SHA: entry.SHA})
+		}
+	}
+	return objects, nil
+}
+
+// Delete 通过 contents API 删除文件，产生一个提交
+func (g *GitHub) Delete(ctx context.Context, key string) error {
+	repoPath, err := g.repoPath(key)
+	if err != nil {
+		return err
+	}
+	info, err := g.stat(ctx, repoPath)
+	if err != nil {
+		return err
+	}
+	request := map[string]string{"message": "Dataset-Sync: 删除 " + repoPath, "sha": info.SHA, "branch": g.cfg.Branch}
+	if err := g.call(ctx, http.MethodDelete, g.contentsURL(repoPath), request, nil); err != nil {
+		return fmt.Errorf("删除 %s 失败: %w", key, err)
+	}
+	return nil
+}
+
+// PutFiles 每 githubBatchFiles 个文件放在同一个提交中：先逐个上传 blob，再创建文件树和提交，最后更新分支
+// 超过大小上限或无法读取的文件跳过并通过 report 报告；一批文件在提交成功后才报告为成功
+func (g *GitHub) PutFiles(ctx context.Context, files []File, report func(file File, err error)) error {
+	for start := 0; start < len(files); start += githubBatchFiles {
+		batch := files[start:min(start+githubBatchFiles, len(files))]
+		var (
+			entries   []treeEntry
+			committed []File
+		)
+		for _, file := range batch {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			repoPath, err := g.repoPath(file.Key)
+			if err == nil && file.Size > GitHubMaxFileSize {
+				err = tooLarge(file.Size)
+			}
+			if err != nil {
+				report(file, err)
+				continue
+			}
+			r, err := file.Open()
+			if err != nil {
+				report(file, err)
+				continue
+			}
+			sha, err := g.createBlob(ctx, r, file.Size)
+			r.Close()
+			if err != nil {
+				return fmt.Errorf("上传 %s 失败: %w", file.Key, err)
+			}
+			entries = append(entries, newTreeEntry(repoPath, sha))
+			committed = append(committed, file)
+		}
+		if len(entries) == 0 {
+			continue
+		}
+		if err := g.commitTree(ctx, entries, fmt.Sprintf("Dataset-Sync: 更新 %d 个文件", len(entries))); err != nil {
+			return err
+		}
+		for _, file := range committed {
+			report(file, nil)
+		}
+	}
+	return nil
+}
+
+// createBlob 上传文件内容，返回 blob 的 SHA
+func (g *GitHub) createBlob(ctx context.Context, r io.Reader, size int64) (string, error) {
+	body, length := blobBody(r, size)
+	defer body.Close()
+	var blob struct {
+		SHA string `json:"sha"`
+	}
+	err := g.callStream(ctx, http.MethodPost, g.repo+"/git/blobs", body, length, &blob)
+	if githubStatus(err, http.StatusConflict) {
+		return "", ErrEmptyRepository
+	}
+	if err != nil {
+		return "", err
+	}
+	return blob.SHA, nil
+}
+
+// treeEntry 文件树中的一项
+type treeEntry struct {
+	Path string `json:"path"`
+	Mode string `json:"mode"`
+	Type string `json:"type"`
+	SHA  string `json:"sha"`
+}
+
+// newTreeEntry 普通文件的文件树项
+func newTreeEntry(repoPath, sha string) treeEntry {
+	return treeEntry{Path: repoPath, Mode: "100644", Type: "blob", SHA: sha}
+}
+
+// head 分支最新的提交及其文件树，分支不存在时均为空；仓库为空时返回 ErrEmptyRepository
+func (g *GitHub) head(ctx context.Context) (commit, tree string, err error) {
+	var ref struct {
+		Object struct {
+			SHA string `json:"sha"`
+		} `json:"object"`
+	}
+	err = g.call(ctx, http.MethodGet, g.repo+"/git/ref/heads/"+g.cfg.Branch, nil, &ref)
+	if githubStatus(err, http.StatusNotFound) {
+		return "", "", nil
+	}
+	if githubStatus(err, http.StatusConflict) {
+		return "", "", ErrEmptyRepository
+	}
+	if err != nil {
+		return "", "", fmt.Errorf("读取分支 %s 失败: %w", g.cfg.Branch, err)
+	}
+	var c struct {
+		Tree struct {
+			SHA string `json:"sha"`
+		} `json:"tree"`
+	}
+	if err := g.call(ctx, http.MethodGet, g.repo+"/git/commits/"+ref.Object.SHA, nil, &c); err != nil {
+		return "", "", fmt.Errorf("读取提交失败: %w", err)
+	}
+	return ref.Object.SHA, c.Tree.SHA, nil
+}
+
+// commitTree 在分支最新的文件树上加入 entries 并提交，分支不存在时创建
+// 分支在此期间被其他提交更新（不能快进）时基于新的提交重试
+func (g *GitHub) commitTree(ctx context.Context, entries []treeEntry, message string) error {
+	for attempt := 1; ; attempt++ {
+		head, baseTree, err := g.head(ctx)
+		if err != nil {
+			return err
+		}
+
+		var tree struct {
+			SHA string `json:"sha"`
+		}
+		treeRequest := struct {
+			BaseTree string      `json:"base_tree,omitempty"`
+			Tree     []treeEntry `json:"tree"`
+		}{baseTree, entries}
+		if err := g.call(ctx, http.MethodPost, g.repo+"/git/trees", treeRequest, &tree); err != nil {
+			return fmt.Errorf("创建文件树失败: %w", err)
+		}
+
+		var commit struct {
+			SHA string `json:"sha"`
+		}
+		commitRequest := struct {
+			Message string   `json:"message"`
+			Tree    string   `json:"tree"`
+			Parents []string `json:"parents"`
+		}{message, tree.SHA, []string{}}
+		if head != "" {
+			commitRequest.Parents = []string{head}
+		}
+		if err := g.call(ctx, http.MethodPost, g.repo+"/git/commits", commitRequest, &commit); err != nil {
+			return fmt.Errorf("创建提交失败: %w", err)
+		}
+
+		if head == "" {
+			err = g.call(ctx, http.MethodPost, g.repo+"/git/refs",
+				map[string]string{"ref": "refs/heads/" + g.cfg.Branch, "sha": commit.SHA}, nil)
+		} else {
+			err = g.call(ctx, http.MethodPatch, g.repo+"/git/refs/heads/"+g.cfg.Branch,
+				map[string]any{"sha": commit.SHA, "force": false}, nil)
+		}
+		if githubStatus(err, http.StatusUnprocessableEntity) && attempt < githubCommitRetries {
+			fmt.Printf("分支 %s 已被更新，重新提交（第 %d 次）\n", g.cfg.Branch, attempt)
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("更新分支 %s 失败: %w", g.cfg.Branch, err)
+		}
+		return nil
+	}
+}
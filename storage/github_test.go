@@ -0,0 +1,332 @@
+package storage
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeGitHub 只实现同步用到的 git-data API 的假 GitHub 仓库
+type fakeGitHub struct {
+	mu      sync.Mutex
+	empty   bool                         // 仓库为空，git-data API 返回 409
+	refs    map[string]string            // 分支 -> 提交
+	commits map[string]fakeCommit        // 提交 SHA -> 提交
+	trees   map[string]map[string]string // 文件树 SHA -> 路径 -> blob SHA
+	blobs   map[string][]byte            // blob SHA -> 内容
+
+	rejectUpdates int // 接下来更新分支时返回 422 的次数
+	created       int // 创建分支的次数
+	updates       int // 更新分支的请求次数（含被拒绝的）
+}
+
+type fakeCommit struct {
+	tree    string
+	parents []string
+}
+
+func newFakeGitHub(t *testing.T) (*fakeGitHub, *GitHub) {
+	t.Helper()
+	fake := &fakeGitHub{
+		refs:    map[string]string{},
+		commits: map[string]fakeCommit{},
+		trees:   map[string]map[string]string{},
+		blobs:   map[string][]byte{},
+	}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+	g, err := NewGitHub(GitHubConfig{Repo: "owner/data", Path: "cats", Token: "token", BaseURL: server.URL}, server.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return fake, g
+}
+
+// hashOf 假仓库中对象的 SHA
+func hashOf(kind string, data []byte) string {
+	sum := sha1.Sum(append([]byte(fmt.Sprintf("%s %d\x00", kind, len(data))), data...))
+	return hex.EncodeToString(sum[:])
+}
+
+func (f *fakeGitHub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	endpoint, ok := strings.CutPrefix(r.URL.Path, "/repos/owner/data/git/")
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	if f.empty {
+		reply(w, http.StatusConflict, map[string]string{"message": "Git Repository is empty."})
+		return
+	}
+	var body map[string]json.RawMessage
+	if r.Body != nil {
+		json.NewDecoder(r.Body).Decode(&body)
+	}
+	field := func(name string, v any) { json.Unmarshal(body[name], v) }
+
+	switch {
+	case r.Method == http.MethodGet && strings.HasPrefix(endpoint, "ref/heads/"):
+		sha, ok := f.refs[strings.TrimPrefix(endpoint, "ref/heads/")]
+		if !ok {
+			reply(w, http.StatusNotFound, map[string]string{"message": "Not Found"})
+			return
+		}
+		reply(w, http.StatusOK, map[string]any{"object": map[string]string{"sha": sha}})
+	case r.Method == http.MethodGet && strings.HasPrefix(endpoint, "commits/"):
+		commit := f.commits[strings.TrimPrefix(endpoint, "commits/")]
+		reply(w, http.StatusOK, map[string]any{"tree": map[string]string{"sha": commit.tree}})
+	case r.Method == http.MethodGet && strings.HasPrefix(endpoint, "trees/"):
+		var entries []map[string]any
+		for p, sha := range f.trees[strings.TrimPrefix(endpoint, "trees/")] {
+			entries = append(entries, map[string]any{"path": p, "type": "blob", "sha": sha, "size": len(f.blobs[sha])})
+		}
+		reply(w, http.StatusOK, map[string]any{"tree": entries, "truncated": false})
+	case r.Method == http.MethodPost && endpoint == "blobs":
+		var content, encoding string
+		field("content", &content)
+		field("encoding", &encoding)
+		data, err := base64.StdEncoding.DecodeString(content)
+		if encoding != "base64" || err != nil {
+			reply(w, http.StatusBadRequest, map[string]string{"message": "bad blob"})
+			return
+		}
+		sha := hashOf("blob", data)
+		f.blobs[sha] = data
+		reply(w, http.StatusCreated, map[string]string{"sha": sha})
+	case r.Method == http.MethodPost && endpoint == "trees":
+		var base string
+		var entries []treeEntry
+		field("base_tree", &base)
+		field("tree", &entries)
+		tree := map[string]string{}
+		for p, sha := range f.trees[base] {
+			tree[p] = sha
+		}
+		for _, entry := range entries {
+			tree[entry.Path] = entry.SHA
+		}
+		data, _ := json.Marshal(tree)
+		sha := hashOf("tree", data)
+		f.trees[sha] = tree
+		reply(w, http.StatusCreated, map[string]string{"sha": sha})
+	case r.Method == http.MethodPost && endpoint == "commits":
+		var commit fakeCommit
+		field("tree", &commit.tree)
+		field("parents", &commit.parents)
+		sha := hashOf("commit", []byte(fmt.Sprint(commit.tree, commit.parents, len(f.commits))))
+		f.commits[sha] = commit
+		reply(w, http.StatusCreated, map[string]string{"sha": sha})
+	case r.Method == http.MethodPost && endpoint == "refs":
+		var ref, sha string
+		field("ref", &ref)
+		field("sha", &sha)
+		f.created++
+		f.refs[strings.TrimPrefix(ref, "refs/heads/")] = sha
+		reply(w, http.StatusCreated, map[string]string{"ref": ref})
+	case r.Method == http.MethodPatch && strings.HasPrefix(endpoint, "refs/heads/"):
+		var sha string
+		field("sha", &sha)
+		f.updates++
+		branch := strings.TrimPrefix(endpoint, "refs/heads/")
+		if f.rejectUpdates > 0 {
+			f.rejectUpdates--
+			// 模拟其他人在此期间推送了提交
+			f.refs[branch] = f.commit(f.refs[branch], map[string]string{"other.txt": hashOf("blob", nil)})
+			reply(w, http.StatusUnprocessableEntity, map[string]string{"message": "Update is not a fast forward"})
+			return
+		}
+		f.refs[branch] = sha
+		reply(w, http.StatusOK, map[string]string{"ref": "refs/heads/" + branch})
+	default:
+		reply(w, http.StatusNotFound, map[string]string{"message": "Not Found"})
+	}
+}
+
+// commit 在 parent 的文件树上加入 files 并直接创建提交，返回提交 SHA
+func (f *fakeGitHub) commit(parent string, files map[string]string) string {
+	tree := map[string]string{}
+	for p, sha := range f.trees[f.commits[parent].tree] {
+		tree[p] = sha
+	}
+	for p, sha := range files {
+		tree[p] = sha
+	}
+	data, _ := json.Marshal(tree)
+	treeSHA := hashOf("tree", data)
+	f.trees[treeSHA] = tree
+	sha := hashOf("commit", []byte(fmt.Sprint(treeSHA, parent, len(f.commits))))
+	f.commits[sha] = fakeCommit{tree: treeSHA, parents: []string{parent}}
+	return sha
+}
+
+// files 分支最新提交中的文件路径（已排序）及其内容
+func (f *fakeGitHub) files(branch string) ([]string, map[string]string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	contents := map[string]string{}
+	var paths []string
+	for p, sha := range f.trees[f.commits[f.refs[branch]].tree] {
+		paths = append(paths, p)
+		contents[p] = string(f.blobs[sha])
+	}
+	sort.Strings(paths)
+	return paths, contents
+}
+
+func reply(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// memFile 内容在内存中的待同步文件
+func memFile(key, content string) File {
+	return File{Key: key, Size: int64(len(content)), Content: []byte(content)}
+}
+
+func TestGitHubSyncCreatesBranchWithOneCommit(t *testing.T) {
+	fake, g := newFakeGitHub(t)
+	files := []File{
+		memFile("a.jpg", "aaa"),
+		memFile("train/b.jpg", "bbbb"),
+		memFile("manifest.jsonl", `{"file":"a.jpg"}`+"\n"),
+	}
+
+	report, err := Sync(context.Background(), g, files, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Uploaded != 3 || report.Skipped != 0 || len(report.Failed) != 0 {
+		t.Fatalf("report = %+v, want 3 uploaded", report)
+	}
+	if fake.created != 1 || fake.updates != 0 {
+		t.Fatalf("created %d branches and updated %d times, want the branch created once", fake.created, fake.updates)
+	}
+	if len(fake.commits) != 1 {
+		t.Fatalf("got %d commits, want 1", len(fake.commits))
+	}
+	for _, commit := range fake.commits {
+		if len(commit.parents) != 0 {
+			t.Fatalf("first commit has parents %v", commit.parents)
+		}
+	}
+	paths, contents := fake.files("main")
+	want := []string{"cats/a.jpg", "cats/manifest.jsonl", "cats/train/b.jpg"}
+	if strings.Join(paths, ",") != strings.Join(want, ",") {
+		t.Fatalf("paths = %v, want %v", paths, want)
+	}
+	if contents["cats/manifest.jsonl"] != `{"file":"a.jpg"}`+"\n" {
+		t.Fatalf("manifest = %q", contents["cats/manifest.jsonl"])
+	}
+
+	// 再次同步时内容相同的文件全部跳过，内容变化（大小不变）的文件重新写入
+	files[0] = memFile("a.jpg", "AAA")
+	report, err = Sync(context.Background(), g, files, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Uploaded != 1 || report.Skipped != 2 {
+		t.Fatalf("second sync report = %+v, want 1 uploaded and 2 skipped", report)
+	}
+	if _, contents := fake.files("main"); contents["cats/a.jpg"] != "AAA" {
+		t.Fatalf("a.jpg = %q after resync", contents["cats/a.jpg"])
+	}
+	if fake.updates != 1 {
+		t.Fatalf("updated the branch %d times, want 1", fake.updates)
+	}
+}
+
+func TestGitHubPutFilesSplitsBatches(t *testing.T) {
+	fake, g := newFakeGitHub(t)
+	files := make([]File, githubBatchFiles+1)
+	for i := range files {
+		files[i] = memFile(fmt.Sprintf("%04d.jpg", i), fmt.Sprint(i))
+	}
+	reported := 0
+	err := g.PutFiles(context.Background(), files, func(file File, err error) {
+		if err != nil {
+			t.Errorf("%s: %v", file.Key, err)
+		}
+		reported++
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reported != len(files) {
+		t.Fatalf("reported %d files, want %d", reported, len(files))
+	}
+	if len(fake.commits) != 2 {
+		t.Fatalf("got %d commits, want 2", len(fake.commits))
+	}
+	if paths, _ := fake.files("main"); len(paths) != len(files) {
+		t.Fatalf("branch has %d files, want %d", len(paths), len(files))
+	}
+}
+
+func TestGitHubPutFilesSkipsLargeFiles(t *testing.T) {
+	fake, g := newFakeGitHub(t)
+	// 超过上限的文件在打开前就被跳过，路径不存在也不影响
+	large := File{Key: "large.tif", Path: "/does/not/exist.tif", Size: GitHubMaxFileSize + 1}
+	report, err := Sync(context.Background(), g, []File{memFile("a.jpg", "aaa"), large}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Uploaded != 1 || len(report.Failed) != 1 || !strings.HasPrefix(report.Failed[0], "large.tif:") {
+		t.Fatalf("report = %+v, want a.jpg uploaded and large.tif failed", report)
+	}
+	if paths, _ := fake.files("main"); len(paths) != 1 || paths[0] != "cats/a.jpg" {
+		t.Fatalf("paths = %v, want only cats/a.jpg", paths)
+	}
+}
+
+func TestGitHubCommitRetriesWhenBranchMoves(t *testing.T) {
+	fake, g := newFakeGitHub(t)
+	ctx := context.Background()
+	if err := g.Put(ctx, "a.jpg", strings.NewReader("aaa"), 3); err != nil {
+		t.Fatal(err)
+	}
+
+	fake.rejectUpdates = 1
+	if err := g.Put(ctx, "b.jpg", strings.NewReader("bbb"), 3); err != nil {
+		t.Fatal(err)
+	}
+	if fake.updates != 2 {
+		t.Fatalf("updated the branch %d times, want 2", fake.updates)
+	}
+	// 重试基于其他人推送后的提交，不会丢失其中的文件
+	paths, _ := fake.files("main")
+	want := []string{"cats/a.jpg", "cats/b.jpg", "other.txt"}
+	if strings.Join(paths, ",") != strings.Join(want, ",") {
+		t.Fatalf("paths = %v, want %v", paths, want)
+	}
+
+	fake.rejectUpdates = githubCommitRetries
+	err := g.Put(ctx, "c.jpg", strings.NewReader("ccc"), 3)
+	if !githubStatus(err, http.StatusUnprocessableEntity) {
+		t.Fatalf("err = %v, want 422 after %d attempts", err, githubCommitRetries)
+	}
+}
+
+func TestGitHubEmptyRepository(t *testing.T) {
+	fake, g := newFakeGitHub(t)
+	fake.empty = true
+	if _, err := Sync(context.Background(), g, []File{memFile("a.jpg", "aaa")}, nil); !errors.Is(err, ErrEmptyRepository) {
+		t.Fatalf("Sync err = %v, want ErrEmptyRepository", err)
+	}
+	if err := g.Put(context.Background(), "a.jpg", strings.NewReader("aaa"), 3); !errors.Is(err, ErrEmptyRepository) {
+		t.Fatalf("Put err = %v, want ErrEmptyRepository", err)
+	}
+}
@@ -0,0 +1,234 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestLocal 根目录在临时目录中的本地后端
+func newTestLocal(t *testing.T) *Local {
+	t.Helper()
+	l, err := NewLocal(LocalConfig{Root: t.TempDir()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return l
+}
+
+// putString 写入字符串内容，失败时终止测试
+func putString(t *testing.T, backend StorageBackend, key, content string) {
+	t.Helper()
+	if err := backend.Put(context.Background(), key, strings.NewReader(content), int64(len(content))); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// readString 读取对象内容，失败时终止测试
+func readString(t *testing.T, backend StorageBackend, key string) string {
+	t.Helper()
+	r, err := backend.Get(context.Background(), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	data, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestCleanKey(t *testing.T) {
+	tests := []struct {
+		key  string
+		want string // 为空表示应返回错误
+	}{
+		{"cats/a.jpg", "cats/a.jpg"},
+		{"/cats/a.jpg", "cats/a.jpg"},
+		{"./cats//a.jpg", "cats/a.jpg"},
+		{`cats\a.jpg`, "cats/a.jpg"},
+		{"../a.jpg", ""},
+		{"cats/../../a.jpg", ""},
+		{`cats\..\..\a.jpg`, ""},
+		{"", ""},
+		{"/", ""},
+	}
+	for _, tt := range tests {
+		got, err := CleanKey(tt.key)
+		if tt.want == "" {
+			if err == nil {
+				t.Errorf("CleanKey(%q) = %q, want an error", tt.key, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("CleanKey(%q) = %q, %v, want %q", tt.key, got, err, tt.want)
+		}
+	}
+}
+
+func TestLocalRejectsTraversal(t *testing.T) {
+	l := newTestLocal(t)
+	if err := l.Put(context.Background(), "../escape.txt", strings.NewReader("x"), 1); err == nil {
+		t.Fatal("want an error for a key outside the root")
+	}
+	if _, err := os.Stat(filepath.Join(filepath.Dir(l.root), "escape.txt")); !errors.Is(err, os.ErrNotExist) {
+		t.Fatal("file was written outside the root")
+	}
+}
+
+func TestLocalPutOverwrites(t *testing.T) {
+	l := newTestLocal(t)
+	putString(t, l, "cats/a.txt", "first")
+	putString(t, l, "cats/a.txt", "second")
+	if got := readString(t, l, "cats/a.txt"); got != "second" {
+		t.Fatalf("content = %q, want the second write", got)
+	}
+	info, err := l.Stat(context.Background(), "/cats/a.txt")
+	if err != nil || info.Key != "cats/a.txt" || info.Size != 6 {
+		t.Fatalf("Stat = %+v, %v", info, err)
+	}
+
+	// 大小不符时不留下不完整的文件，原有内容不变
+	if err := l.Put(context.Background(), "cats/a.txt", strings.NewReader("short"), 10); err == nil {
+		t.Fatal("want an error when the size does not match")
+	}
+	if got := readString(t, l, "cats/a.txt"); got != "second" {
+		t.Fatalf("content = %q after a failed write", got)
+	}
+	if objects, _ := l.List(context.Background(), ""); len(objects) != 1 {
+		t.Fatalf("%d objects after a failed write, want no temporary files", len(objects))
+	}
+}
+
+func TestLocalListPrefix(t *testing.T) {
+	l := newTestLocal(t)
+	for _, key := range []string{"cats/a.txt", "cats/b.txt", "catsup.txt", "dogs/a.txt"} {
+		putString(t, l, key, key)
+	}
+	// 以 . 开头的临时文件不列出
+	os.WriteFile(filepath.Join(l.root, "cats", ".upload-1"), []byte("x"), 0o644)
+
+	tests := []struct {
+		prefix string
+		want   []string
+	}{
+		{"", []string{"cats/a.txt", "cats/b.txt", "catsup.txt", "dogs/a.txt"}},
+		{"cats/", []string{"cats/a.txt", "cats/b.txt"}},
+		{"cats", []string{"cats/a.txt", "cats/b.txt", "catsup.txt"}},
+		{"/dogs", []string{"dogs/a.txt"}},
+		{"birds/", nil},
+	}
+	for _, tt := range tests {
+		objects, err := l.List(context.Background(), tt.prefix)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var keys []string
+		for _, object := range objects {
+			keys = append(keys, object.Key)
+		}
+		sort.Strings(keys)
+		if strings.Join(keys, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("List(%q) = %v, want %v", tt.prefix, keys, tt.want)
+		}
+	}
+}
+
+func TestLocalDelete(t *testing.T) {
+	l := newTestLocal(t)
+	putString(t, l, "cats/a.txt", "a")
+	if err := l.Delete(context.Background(), "cats/a.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := l.Stat(context.Background(), "cats/a.txt"); !errors.Is(err, ErrNotExist) {
+		t.Fatalf("Stat err = %v, want ErrNotExist", err)
+	}
+	if _, err := l.Get(context.Background(), "cats/a.txt"); !errors.Is(err, ErrNotExist) {
+		t.Fatalf("Get err = %v, want ErrNotExist", err)
+	}
+	if err := l.Delete(context.Background(), "cats/a.txt"); !errors.Is(err, ErrNotExist) {
+		t.Fatalf("Delete err = %v, want ErrNotExist", err)
+	}
+}
+
+func TestSyncSkipsByModTime(t *testing.T) {
+	l := newTestLocal(t)
+	dir := t.TempDir()
+	path := filepath.Join(dir, "a.txt")
+	os.WriteFile(path, []byte("aaa"), 0o644)
+	old := time.Now().Add(-time.Hour)
+	os.Chtimes(path, old, old)
+	files := []File{
+		{Key: "a.txt", Path: path, Size: 3},
+		{Key: "manifest.json", Content: []byte("{}"), Size: 2},
+	}
+
+	report, err := Sync(context.Background(), l, files, nil)
+	if err != nil || report.Uploaded != 2 || report.Skipped != 0 {
+		t.Fatalf("first sync = %+v, %v, want 2 uploaded", report, err)
+	}
+
+	// 本地文件没有变化：同步目录中的文件不早于本地文件，跳过
+	var progress []int
+	report, err = Sync(context.Background(), l, files, func(done, total int) { progress = append(progress, done) })
+	if err != nil || report.Uploaded != 0 || report.Skipped != 2 {
+		t.Fatalf("second sync = %+v, %v, want 2 skipped", report, err)
+	}
+	if len(progress) != 2 || progress[1] != 2 {
+		t.Fatalf("progress = %v, want 1 and 2", progress)
+	}
+
+	// 大小不变但本地文件更新过，重新写入
+	os.WriteFile(path, []byte("bbb"), 0o644)
+	future := time.Now().Add(time.Hour)
+	os.Chtimes(path, future, future)
+	report, err = Sync(context.Background(), l, files, nil)
+	if err != nil || report.Uploaded != 1 || report.Skipped != 1 {
+		t.Fatalf("sync after a change = %+v, %v, want 1 uploaded and 1 skipped", report, err)
+	}
+	if got := readString(t, l, "a.txt"); got != "bbb" {
+		t.Fatalf("content = %q, want the updated file", got)
+	}
+}
+
+func TestSyncReportsFailedFiles(t *testing.T) {
+	l := newTestLocal(t)
+	path := filepath.Join(t.TempDir(), "a.txt")
+	os.WriteFile(path, []byte("a"), 0o644)
+	files := []File{
+		{Key: "missing.txt", Path: filepath.Join(t.TempDir(), "missing.txt"), Size: 1},
+		{Key: "../escape.txt", Path: path, Size: 1},
+		{Key: "a.txt", Path: path, Size: 1},
+	}
+
+	report, err := Sync(context.Background(), l, files, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Uploaded != 1 || len(report.Failed) != 2 {
+		t.Fatalf("report = %+v, want 1 uploaded and 2 failed", report)
+	}
+	for i, key := range []string{"missing.txt", "../escape.txt"} {
+		if !strings.HasPrefix(report.Failed[i], key+": ") {
+			t.Errorf("Failed[%d] = %q, want it to name %s", i, report.Failed[i], key)
+		}
+	}
+}
+
+func TestSyncStopsWhenCancelled(t *testing.T) {
+	l := newTestLocal(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := Sync(ctx, l, []File{{Key: "a.txt", Content: []byte("a"), Size: 1}}, nil)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v, want context.Canceled", err)
+	}
+}
@@ -0,0 +1,122 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+)
+
+// 存储后端类型，保存在数据集设置中
+const (
+	BackendNone   = ""       // 不同步，文件只保存在本机的存放目录
+	BackendLocal  = "local"  // 本地文件系统中的另一个目录，如移动硬盘、网络共享盘
+	BackendGitHub = "github" // GitHub 仓库
+)
+
+// ErrNotExist 对象不存在
+var ErrNotExist = errors.New("对象不存在")
+
+// ObjectInfo 存储中的一个对象（文件）
+type ObjectInfo struct {
+	Key     string    // 对象的键，使用 / 分隔的相对路径，如 cats/a.jpg
+	Size    int64     // 大小（字节）
+	ModTime time.Time // 修改时间，后端不提供时为零值
+	SHA     string    // 内容的 git blob SHA（见 GitBlobSHA），后端不提供时为空
+}
+
+// StorageBackend 数据集文件的存储后端，键为使用 / 分隔的相对路径
+// 读写均为流式，可通过 ctx 取消；对象不存在时 Get、Stat、Delete 返回 ErrNotExist
+type StorageBackend interface {
+	// Put 写入对象，已存在时覆盖；size 为数据大小，未知时传 -1
+	Put(ctx context.Context, key string, r io.Reader, size int64) error
+	// Get 读取对象，调用方负责关闭
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Stat 获取对象信息
+	Stat(ctx context.Context, key string) (ObjectInfo, error)
+	// List 列出键以 prefix 开头的全部对象，prefix 为空时列出全部
+	List(ctx context.Context, prefix string) ([]ObjectInfo, error)
+	// Delete 删除对象
+	Delete(ctx context.Context, key string) error
+}
+
+// BatchPutter 可以一次写入多个文件的存储后端，如 GitHub 把多个文件放在同一个提交中，Sync 优先使用
+type BatchPutter interface {
+	// PutFiles 写入多个文件，每个文件写入成功或失败后回调 report；
+	// 单个文件的问题（如无法读取、超过大小上限）通过 report 报告并继续，其他错误中止并返回
+	PutFiles(ctx context.Context, files []File, report func(file File, err error)) error
+}
+
+// New 按后端类型和设置（JSON 对象）创建存储后端
+func New(backend, config string) (StorageBackend, error) {
+	switch backend {
+	case BackendLocal:
+		var cfg LocalConfig
+		if err := decodeConfig(config, &cfg); err != nil {
+			return nil, err
+		}
+		return NewLocal(cfg)
+	case BackendGitHub:
+		var cfg GitHubConfig
+		if err := decodeConfig(config, &cfg); err != nil {
+			return nil, err
+		}
+		return NewGitHub(cfg, nil)
+	case BackendNone:
+		return nil, errors.New("数据集未设置存储后端")
+	default:
+		return nil, fmt.Errorf("不支持的存储后端: %s", backend)
+	}
+}
+
+// decodeConfig 解析后端设置，空字符串视为空对象
+func decodeConfig(config string, v any) error {
+	if strings.TrimSpace(config) == "" {
+		return nil
+	}
+	if err := json.Unmarshal([]byte(config), v); err != nil {
+		return fmt.Errorf("解析存储后端设置失败: %w", err)
+	}
+	return nil
+}
+
+// EncodeConfig 将后端设置编码为保存在数据集中的 JSON 对象
+func EncodeConfig(v any) (string, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", fmt.Errorf("编码存储后端设置失败: %w", err)
+	}
+	return string(data), nil
+}
+
+// CleanKey 统一键的写法：使用 / 分隔，去掉开头的 / 和 ./；空键或包含 .. 时返回错误
+func CleanKey(key string) (string, error) {
+	key = strings.ReplaceAll(key, `\`, "/")
+	for _, part := range strings.Split(key, "/") {
+		if part == ".." {
+			return "", fmt.Errorf("对象的键不能包含 ..: %s", key)
+		}
+	}
+	key = strings.TrimPrefix(path.Clean("/"+key), "/")
+	if key == "" {
+		return "", errors.New("对象的键不能为空")
+	}
+	return key, nil
+}
+
+// contextReader 读取时检查 ctx 是否已取消
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (c *contextReader) Read(b []byte) (int, error) {
+	if err := c.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return c.r.Read(b)
+}
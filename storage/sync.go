@@ -0,0 +1,158 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+)
+
+// File 待同步的文件
+type File struct {
+	Key     string // 在存储后端中的键
+	Path    string // 本地路径
+	Size    int64  // 文件大小（字节）
+	Content []byte // 在内存中生成的内容（如清单文件），非空时忽略 Path
+}
+
+// Open 打开文件内容，调用方负责关闭
+func (f File) Open() (io.ReadCloser, error) {
+	if f.Content != nil {
+		return io.NopCloser(bytes.NewReader(f.Content)), nil
+	}
+	file, err := os.Open(f.Path)
+	if err != nil {
+		return nil, fmt.Errorf("打开文件失败: %w", err)
+	}
+	return file, nil
+}
+
+// GitBlobSHA 计算文件内容的 git blob SHA，即 sha1("blob <大小>\x00" + 内容)，与 GitHub 文件树中的 SHA 一致
+// 以流的方式读取，实际大小与 Size 不符时返回错误
+func (f File) GitBlobSHA() (string, error) {
+	r, err := f.Open()
+	if err != nil {
+		return "", err
+	}
+	defer r.Close()
+	hash := sha1.New()
+	fmt.Fprintf(hash, "blob %d\x00", f.Size)
+	n, err := io.Copy(hash, r)
+	if err != nil {
+		return "", fmt.Errorf("读取文件失败: %w", err)
+	}
+	if n != f.Size {
+		return "", fmt.Errorf("文件大小 %d 字节，应为 %d 字节", n, f.Size)
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// SyncReport 同步结果
+type SyncReport struct {
+	Uploaded int      // 写入的文件数
+	Skipped  int      // 存储后端中已有相同对象而跳过的文件数
+	Failed   []string // 写入失败的文件及原因
+}
+
+// Sync 把文件推送到存储后端：已有同键且内容相同的对象时跳过（判断方法见 unchanged），其余写入
+// 后端实现了 BatchPutter 时一次写入全部文件，否则逐个写入；存储后端中多出的对象不会删除
+// 单个文件失败时继续同步其余文件，ctx 取消时立即返回
+func Sync(ctx context.Context, backend StorageBackend, files []File, progress func(done, total int)) (SyncReport, error) {
+	var report SyncReport
+	existing, err := backend.List(ctx, "")
+	if err != nil {
+		return report, err
+	}
+	objects := make(map[string]ObjectInfo, len(existing))
+	for _, object := range existing {
+		objects[object.Key] = object
+	}
+
+	done := 0
+	step := func() {
+		done++
+		if progress != nil {
+			progress(done, len(files))
+		}
+	}
+	var pending []File
+	for _, file := range files {
+		if object, ok := objects[file.Key]; ok && unchanged(ctx, backend, object, file) {
+			report.Skipped++
+			step()
+		} else {
+			pending = append(pending, file)
+		}
+	}
+
+	result := func(file File, err error) {
+		if err != nil {
+			report.Failed = append(report.Failed, fmt.Sprintf("%s: %v", file.Key, err))
+		} else {
+			report.Uploaded++
+		}
+		step()
+	}
+	if batch, ok := backend.(BatchPutter); ok {
+		if err := batch.PutFiles(ctx, pending, result); err != nil {
+			return report, err
+		}
+		return report, nil
+	}
+	for _, file := range pending {
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+		err := putFile(ctx, backend, file)
+		if err != nil && ctx.Err() != nil {
+			return report, ctx.Err()
+		}
+		result(file, err)
+	}
+	return report, nil
+}
+
+// unchanged 存储后端中的对象与待同步的文件是否相同，大小不同时总是不同；大小相同时：
+// 后端提供 SHA 时比较内容的 git blob SHA；内存中生成的文件读取对象比较内容；
+// 后端提供修改时间时，对象不早于本地文件才视为相同；都不提供时只比较大小
+func unchanged(ctx context.Context, backend StorageBackend, object ObjectInfo, file File) bool {
+	if object.Size != file.Size {
+		return false
+	}
+	if object.SHA != "" {
+		sha, err := file.GitBlobSHA()
+		return err == nil && sha == object.SHA
+	}
+	if file.Content != nil {
+		return sameContent(ctx, backend, file)
+	}
+	if object.ModTime.IsZero() {
+		return true
+	}
+	info, err := os.Stat(file.Path)
+	return err == nil && !object.ModTime.Before(info.ModTime())
+}
+
+// sameContent 存储后端中的对象与内存中生成的文件内容是否相同
+func sameContent(ctx context.Context, backend StorageBackend, file File) bool {
+	r, err := backend.Get(ctx, file.Key)
+	if err != nil {
+		return false
+	}
+	defer r.Close()
+	data, err := io.ReadAll(io.LimitReader(r, file.Size+1))
+	return err == nil && bytes.Equal(data, file.Content)
+}
+
+// putFile 以流的方式写入一个文件
+func putFile(ctx context.Context, backend StorageBackend, file File) error {
+	r, err := file.Open()
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	return backend.Put(ctx, file.Key, r, file.Size)
+}
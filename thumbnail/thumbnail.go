@@ -0,0 +1,215 @@
+// Package thumbnail 生成并缓存图片缩略图，缓存文件按内容哈希和尺寸命名，超过容量上限时淘汰最久未使用的文件
+package thumbnail
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"golang.org/x/image/draw"
+
+	// 注册支持的图片解码器，从原图生成缩略图时使用
+	_ "image/gif"
+	_ "image/png"
+
+	_ "golang.org/x/image/bmp"
+	_ "golang.org/x/image/tiff"
+	_ "golang.org/x/image/webp"
+)
+
+// 常用的缩略图尺寸（最长边像素）
+const (
+	SizeCard    = 300 // 数据集卡片封面
+	SizeGallery = 200 // 图片列表、查重窗口
+)
+
+// Sizes 入库时预先生成的尺寸
+var Sizes = []int{SizeCard, SizeGallery}
+
+const (
+	DefaultMaxBytes = 512 << 20 // 默认缓存容量上限 512MB
+	quality         = 85        // 缩略图 JPEG 质量
+	ext             = ".jpg"
+)
+
+// Cache 磁盘缩略图缓存
+type Cache struct {
+	Dir      string // 缓存目录
+	MaxBytes int64  // 容量上限（字节），超过时淘汰最久未使用的缩略图
+}
+
+// New 创建缩略图缓存，maxBytes 为 0 时使用默认上限
+func New(dir string, maxBytes int64) *Cache {
+	if maxBytes <= 0 {
+		maxBytes = DefaultMaxBytes
+	}
+	return &Cache{Dir: dir, MaxBytes: maxBytes}
+}
+
+// Path 缩略图文件路径：<目录>/<哈希前两位>/<哈希>_<尺寸>.jpg
+func (c *Cache) Path(sha256 string, size int) string {
+	prefix := sha256
+	if len(prefix) > 2 {
+		prefix = prefix[:2]
+	}
+	return filepath.Join(c.Dir, prefix, fmt.Sprintf("%s_%d%s", sha256, size, ext))
+}
+
+// Generate 由已解码的图片生成缩略图并写入缓存，已存在时直接覆盖
+func (c *Cache) Generate(img image.Image, sha256 string, size int) (string, error) {
+	if sha256 == "" {
+		return "", errors.New("缺少图片哈希")
+	}
+	path := c.Path(sha256, size)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return "", fmt.Errorf("创建缩略图目录失败: %w", err)
+	}
+
+	// 先写临时文件再改名，避免界面读到写了一半的缩略图
+	tmp := path + "." + randomSuffix() + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return "", fmt.Errorf("创建缩略图失败: %w", err)
+	}
+	err = jpeg.Encode(f, Scale(img, size), &jpeg.Options{Quality: quality})
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp, path)
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return "", fmt.Errorf("写入缩略图失败: %w", err)
+	}
+	return path, nil
+}
+
+// Get 返回缩略图路径，缓存中没有时从原图 srcPath 生成
+// 命中时更新文件修改时间，作为淘汰时的最近使用时间
+func (c *Cache) Get(sha256, srcPath string, size int) (string, error) {
+	path := c.Path(sha256, size)
+	if _, err := os.Stat(path); err == nil {
+		now := time.Now()
+		os.Chtimes(path, now, now)
+		return path, nil
+	}
+
+	f, err := os.Open(srcPath)
+	if err != nil {
+		return "", fmt.Errorf("打开原图失败: %w", err)
+	}
+	defer f.Close()
+	img, _, err := image.Decode(f)
+	if err != nil {
+		return "", fmt.Errorf("解码原图失败: %w", err)
+	}
+	return c.Generate(img, sha256, size)
+}
+
+// Scale 等比缩放到最长边不超过 size，背景透明的部分合成到白色上
+func Scale(img image.Image, size int) image.Image {
+	bounds := img.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+	if w > size || h > size {
+		if w >= h {
+			w, h = size, max(1, h*size/w)
+		} else {
+			w, h = max(1, w*size/h), size
+		}
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, bounds, draw.Over, nil)
+	return dst
+}
+
+// entry 缓存中的一个文件
+type entry struct {
+	path    string
+	size    int64
+	modTime time.Time
+}
+
+// list 列出缓存中的全部缩略图
+func (c *Cache) list() ([]entry, int64, error) {
+	var (
+		entries []entry
+		total   int64
+	)
+	err := filepath.WalkDir(c.Dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if d.IsDir() || !strings.HasSuffix(path, ext) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil // 文件可能刚被删除
+		}
+		entries = append(entries, entry{path: path, size: info.Size(), modTime: info.ModTime()})
+		total += info.Size()
+		return nil
+	})
+	if err != nil {
+		return nil, 0, fmt.Errorf("读取缩略图缓存失败: %w", err)
+	}
+	return entries, total, nil
+}
+
+// Usage 缓存当前占用的字节数和文件数
+func (c *Cache) Usage() (int64, int, error) {
+	entries, total, err := c.list()
+	return total, len(entries), err
+}
+
+// Evict 缓存超过容量上限时，按最近使用时间从旧到新删除，直到不超过上限，返回删除的文件数
+func (c *Cache) Evict() (int, error) {
+	entries, total, err := c.list()
+	if err != nil || total <= c.MaxBytes {
+		return 0, err
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].modTime.Before(entries[j].modTime) })
+
+	removed := 0
+	for _, e := range entries {
+		if total <= c.MaxBytes {
+			break
+		}
+		if err := os.Remove(e.path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return removed, fmt.Errorf("删除缩略图失败: %w", err)
+		}
+		total -= e.size
+		removed++
+	}
+	return removed, nil
+}
+
+// Clear 清空缓存目录
+func (c *Cache) Clear() error {
+	if err := os.RemoveAll(c.Dir); err != nil {
+		return fmt.Errorf("清空缩略图缓存失败: %w", err)
+	}
+	return nil
+}
+
+// randomSuffix 临时文件后缀，避免并发生成同一缩略图时互相覆盖
+func randomSuffix() string {
+	b := make([]byte, 4)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
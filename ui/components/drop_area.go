@@ -61,14 +61,16 @@ func (d *DropZone) ResetHighlight() {
 	d.background.Refresh()
 }
 
-// CreateDropArea 创建拖放区域
-func CreateDropArea(window fyne.Window, content *fyne.Container) *DropZone {
+// CreateDropArea 创建拖放区域，文件放下后交给 onDropped 处理
+func CreateDropArea(window fyne.Window, content *fyne.Container, onDropped func([]fyne.URI)) *DropZone {
 	// 创建拖放区域
 	dropZone := NewDropZone(window, container.NewCenter(content), func(uris []fyne.URI) {
-		fmt.Println("Dropped files:")
 		for _, uri := range uris {
 			fmt.Printf("Dropped file: %s\n", uri.Path())
 		}
+		if onDropped != nil {
+			onDropped(uris)
+		}
 	})
 
 	// 绑定窗口的拖放事件
@@ -2,9 +2,15 @@ package ui
 
 import (
 	"dataset-sync/database"
+	"dataset-sync/ingest"
 	"dataset-sync/models"
+	"dataset-sync/thumbnail"
+	"dataset-sync/utils"
 	"fyne.io/fyne/v2/dialog"
+	"math"
+	"os"
 	"sort"
+	"strconv"
 	"strings"
 
 	"fyne.io/fyne/v2/canvas"
@@ -17,22 +23,36 @@ import (
 	"fyne.io/fyne/v2/widget"
 )
 
-var cardSize = fyne.NewSize(240, 500)                           // 每张卡片尺寸
-var Datasets []*models.Dataset                                  // 全局数据集列表
-var grid *fyne.Container = container.NewGridWrap(cardSize, nil) // 全局网格容器grid
-var curDatasets []*models.Dataset                               // 当前数据集列表
-var datasetCards = make(map[string]fyne.CanvasObject)           // 存储数据集卡片的映射
+var cardSize = fyne.NewSize(240, 500) // 每张卡片尺寸
 
-func CreateDatasets() *fyne.Container {
-	gridScroll := container.NewScroll(grid) // 创建滚动容器
-	// 获取数据集列表
-	Datasets = database.GetDatasets()                      // 从SQL中获取Dataset切片
-	curDatasets = append([]*models.Dataset{}, Datasets...) // 初始化当前数据集列表
-	// 初始化卡片
-	initDatasetCards()
+// DatasetView 数据集界面，数据来源于 DatasetRepository
+type DatasetView struct {
+	repo         database.DatasetRepository   // 数据集存储
+	ingester     *ingest.Ingester             // 图片导入与删除
+	datasets     []*models.Dataset            // 全部数据集列表
+	curDatasets  []*models.Dataset            // 当前数据集列表（搜索、排序后）
+	grid         *fyne.Container              // 网格容器
+	datasetCards map[string]fyne.CanvasObject // 存储数据集卡片的映射
+	Content      *fyne.Container              // 整体布局
+}
 
-	// 初始化网格
-	updateGrid()
+// NewDatasetView 创建数据集界面
+func NewDatasetView(repo database.DatasetRepository, ingester *ingest.Ingester) *DatasetView {
+	v := &DatasetView{
+		repo:         repo,
+		ingester:     ingester,
+		grid:         container.NewGridWrap(cardSize, nil),
+		datasetCards: make(map[string]fyne.CanvasObject),
+	}
+	v.Content = v.createContent()
+	return v
+}
+
+// createContent 创建数据集界面布局
+func (v *DatasetView) createContent() *fyne.Container {
+	gridScroll := container.NewScroll(v.grid) // 创建滚动容器
+	// 获取数据集列表
+	v.Reload()
 
 	// 创建新增数据集按钮
 	addDatasetButton := widget.NewButtonWithIcon("", theme.ContentAddIcon(), func() {
@@ -55,6 +75,12 @@ func CreateDatasets() *fyne.Container {
 				dialog.ShowError(fmt.Errorf("名称不能为空"), ui.window)
 				return
 			}
+			ds := &models.Dataset{Name: name}
+			if err := v.repo.CreateDataset(ds); err != nil {
+				dialog.ShowError(err, ui.window)
+				return
+			}
+			v.addDataset(ds)
 			dialog.ShowCustom("数据集添加成功！", "确定",
 				widget.NewLabel("新数据集名称: "+name), ui.window)
 			fmt.Println("新数据集：", name)
@@ -69,7 +95,7 @@ func CreateDatasets() *fyne.Container {
 	searchEntry := widget.NewEntry()           // 初始化搜索输入框
 	searchEntry.Resize(fyne.NewSize(400, 400)) // 增加搜索框的宽度和高度
 	searchEntry.OnChanged = func(keyword string) {
-		searchDatasets(keyword)
+		v.searchDatasets(keyword)
 	}
 	searchEntry.Hide()
 
@@ -81,14 +107,14 @@ func CreateDatasets() *fyne.Container {
 		} else {
 			searchEntry.Hide()
 			searchEntry.SetText("") // 清空搜索内容
-			searchDatasets("")      // 重置数据集显示
+			v.searchDatasets("")    // 重置数据集显示
 		}
 	})
 	searchButton.Resize(fyne.NewSize(40, 40)) // 调整搜索按钮大小
 
 	// 创建排序复选菜单 -- 两个单选框组合
 	sortTypeMenu := widget.NewSelect([]string{"名称", "数量", "日期"}, func(selected string) {
-		sortDatasets(selected)
+		v.sortDatasets(selected)
 	})
 	sortTypeMenu.PlaceHolder = "排序"
 
@@ -113,7 +139,7 @@ func CreateDatasets() *fyne.Container {
 
 	// 使用点击事件隐藏搜索框
 	searchEntry.OnChanged = func(keyword string) {
-		searchDatasets(keyword)
+		v.searchDatasets(keyword)
 		if keyword == "" {
 			searchEntry.Hide()
 		}
@@ -123,29 +149,52 @@ func CreateDatasets() *fyne.Container {
 	return container.NewBorder(topNav, nil, nil, nil, gridScroll)
 }
 
+// Reload 从存储中重新读取数据集并刷新界面
+func (v *DatasetView) Reload() {
+	datasets, err := v.repo.ListDatasets() // 从存储中获取Dataset切片
+	if err != nil {
+		fmt.Println("获取数据集失败:", err)
+	}
+	v.datasets = datasets
+	v.curDatasets = append([]*models.Dataset{}, v.datasets...) // 初始化当前数据集列表
+	// 初始化卡片
+	v.initDatasetCards()
+
+	// 初始化网格
+	v.updateGrid()
+}
+
+// addDataset 将新建的数据集加入列表并刷新网格
+func (v *DatasetView) addDataset(ds *models.Dataset) {
+	v.datasets = append(v.datasets, ds)
+	v.curDatasets = append(v.curDatasets, ds)
+	v.datasetCards[ds.Name] = v.createDatasetCard(ds)
+	v.updateGrid()
+}
+
 // searchDatasets 搜索数据集
-func searchDatasets(keyword string) {
+func (v *DatasetView) searchDatasets(keyword string) {
 	if keyword == "" {
-		curDatasets = append([]*models.Dataset{}, Datasets...) // 重置当前数据集列表
-		updateGrid()
+		v.curDatasets = append([]*models.Dataset{}, v.datasets...) // 重置当前数据集列表
+		v.updateGrid()
 		return
 	}
 	// 搜索过滤
 	var filtered []*models.Dataset
-	for _, item := range Datasets {
+	for _, item := range v.datasets {
 		if strings.Contains(strings.ToLower(item.Name), strings.ToLower(keyword)) {
 			filtered = append(filtered, item)
 		}
 	}
 	// 更新当前数据集列表
-	curDatasets = filtered
-	updateGrid()
+	v.curDatasets = filtered
+	v.updateGrid()
 }
 
 // sortDatasets 对数据集进行排序
-func sortDatasets(option string) {
-	sorted := make([]*models.Dataset, len(curDatasets))
-	copy(sorted, curDatasets)
+func (v *DatasetView) sortDatasets(option string) {
+	sorted := make([]*models.Dataset, len(v.curDatasets))
+	copy(sorted, v.curDatasets)
 
 	// 根据选择的排序类型和顺序进行排序
 	switch option {
@@ -156,47 +205,49 @@ func sortDatasets(option string) {
 	case "日期":
 		sort.Slice(sorted, func(i, j int) bool { return sorted[i].UpdatedAt.Before(sorted[j].UpdatedAt) })
 	}
-	// 更新全局数据集并刷新网格显示
-	curDatasets = sorted
-	updateGrid()
+	// 更新当前数据集并刷新网格显示
+	v.curDatasets = sorted
+	v.updateGrid()
 }
 
 // updateGrid 更新网格显示
-func updateGrid() {
-	grid.Objects = nil // 清空网格对象
-	if len(curDatasets) == 0 {
-		grid.Add(widget.NewLabel("无该数据集"))
+func (v *DatasetView) updateGrid() {
+	v.grid.Objects = nil // 清空网格对象
+	if len(v.curDatasets) == 0 {
+		v.grid.Add(widget.NewLabel("无该数据集"))
 		return
 	}
 
 	var cards []fyne.CanvasObject
-	for _, item := range curDatasets {
-		cards = append(cards, datasetCards[item.Name])
+	for _, item := range v.curDatasets {
+		cards = append(cards, v.datasetCards[item.Name])
 	}
-	grid.Objects = cards
-	grid.Refresh()
+	v.grid.Objects = cards
+	v.grid.Refresh()
 }
 
 // initDatasetCards 初始化数据集卡片
-func initDatasetCards() {
-	// 初始化数据集卡片
-	for _, ds := range Datasets {
-		card := createDatasetCard(ds)
-		datasetCards[ds.Name] = card
+func (v *DatasetView) initDatasetCards() {
+	v.datasetCards = make(map[string]fyne.CanvasObject)
+	for _, ds := range v.datasets {
+		card := v.createDatasetCard(ds)
+		v.datasetCards[ds.Name] = card
 	}
 }
 
 // createDatasetCard 创建数据集卡片
-func createDatasetCard(ds *models.Dataset) fyne.CanvasObject {
+func (v *DatasetView) createDatasetCard(ds *models.Dataset) fyne.CanvasObject {
 	// 获取封面图
-	thumbnail := getCover(ds.Cover)
+	cover := v.getCover(ds)
 
 	// 卡牌内容
 	content := container.NewVBox(
 		// 使用 container.NewPadded 为封面图片添加内边距
-		container.NewPadded(thumbnail),
+		container.NewPadded(cover),
 		widget.NewLabelWithStyle(ds.Name, fyne.TextAlignCenter, fyne.TextStyle{Bold: true}),
-		widget.NewLabel(fmt.Sprintf("图片数量: %d", ds.ImageCount)),
+		widget.NewLabel(fmt.Sprintf("图片数量: %s", quotaUsage(ds.ImageCount, ds.MaxImages))),
+		widget.NewLabel(fmt.Sprintf("总大小: %s", sizeUsage(ds.TotalSize, ds.MaxSize))),
+		widget.NewLabel(fmt.Sprintf("重复图片: %d", ds.DuplicateCount)),
 		widget.NewLabel(fmt.Sprintf("最后更新日期: %s", ds.UpdatedAt.Format("2006-01-02"))),
 		// 状态 0 -- 未更新 1 -- 已更新
 		widget.NewLabel(fmt.Sprintf("状态: %s", func() string {
@@ -205,6 +256,20 @@ func createDatasetCard(ds *models.Dataset) fyne.CanvasObject {
 			}
 			return "已更新"
 		}())),
+		container.NewGridWithColumns(2,
+			widget.NewButtonWithIcon("查重", theme.SearchIcon(), func() {
+				showDuplicateWindow(v.ingester, ds)
+			}),
+			widget.NewButtonWithIcon("监控", theme.FolderOpenIcon(), func() {
+				showWatchFolders(ds)
+			}),
+			widget.NewButtonWithIcon("同步", theme.UploadIcon(), func() {
+				v.syncDataset(ds)
+			}),
+			widget.NewButtonWithIcon("设置", theme.SettingsIcon(), func() {
+				v.showSettingsDialog(ds)
+			}),
+		),
 	)
 
 	// 使用 container.NewStack 确保背景和内容完全重叠
@@ -214,13 +279,172 @@ func createDatasetCard(ds *models.Dataset) fyne.CanvasObject {
 	return cardContainer
 }
 
-// getCover 获取数据集封面图
-func getCover(filepath string) *canvas.Image {
-	// 加载图片
-	thumbnail := canvas.NewImageFromFile(filepath)
-	thumbnail.FillMode = canvas.ImageFillContain // 或者 ImageFillStretch，确保缩放适应容器
+// normalizeFormats 归一化格式选项与显示名称
+var normalizeFormats = []struct {
+	format string
+	label  string
+}{
+	{ingest.NormalizeNone, "保留原格式"},
+	{ingest.NormalizeJPEG, "JPEG"},
+	{ingest.NormalizePNG, "PNG"},
+}
+
+// quotaUsage 图片数量，设置了上限时显示为“已有 / 上限”
+func quotaUsage(count, limit int) string {
+	if limit <= 0 {
+		return strconv.Itoa(count)
+	}
+	return fmt.Sprintf("%d / %d", count, limit)
+}
+
+// sizeUsage 总大小，设置了上限时显示为“已有 / 上限”
+func sizeUsage(size, limit int64) string {
+	if limit <= 0 {
+		return utils.FormatSize(size)
+	}
+	return utils.FormatSize(size) + " / " + utils.FormatSize(limit)
+}
+
+// parseLimit 解析容量上限输入，空或 0 表示不限制
+func parseLimit(text string) (float64, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return 0, nil
+	}
+	value, err := strconv.ParseFloat(text, 64)
+	if err != nil || value < 0 || math.IsInf(value, 0) {
+		return 0, fmt.Errorf("请输入非负数: %s", text)
+	}
+	return value, nil
+}
+
+// limitText 容量上限的输入框文本，不限制时为空
+func limitText(value float64) string {
+	if value <= 0 {
+		return ""
+	}
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
+
+// showSettingsDialog 编辑数据集设置：入库格式归一化、容量上限和同步到的存储后端
+func (v *DatasetView) showSettingsDialog(ds *models.Dataset) {
+	labels := make([]string, len(normalizeFormats))
+	for i, item := range normalizeFormats {
+		labels[i] = item.label
+	}
+	formatSelect := widget.NewSelect(labels, nil)
+	formatSelect.SetSelectedIndex(0)
+	for i, item := range normalizeFormats {
+		if item.format == ds.NormalizeFormat {
+			formatSelect.SetSelectedIndex(i)
+		}
+	}
+
+	quality := ds.NormalizeQuality
+	if quality <= 0 {
+		quality = ingest.DefaultJPEGQuality
+	}
+	qualityLabel := widget.NewLabel(fmt.Sprintf("%d", quality))
+	qualitySlider := widget.NewSlider(1, 100)
+	qualitySlider.Step = 1
+	qualitySlider.SetValue(float64(quality))
+	qualitySlider.OnChanged = func(value float64) {
+		qualityLabel.SetText(fmt.Sprintf("%d", int(value)))
+	}
+
+	storageForm := newStorageForm(ui.window, ds)
+
+	maxImagesEntry := widget.NewEntry()
+	maxImagesEntry.SetPlaceHolder("不限制")
+	maxImagesEntry.SetText(limitText(float64(ds.MaxImages)))
+	maxImagesEntry.Validator = func(text string) error {
+		value, err := parseLimit(text)
+		if err == nil && value != math.Trunc(value) {
+			err = fmt.Errorf("请输入整数: %s", text)
+		}
+		return err
+	}
+	maxSizeEntry := widget.NewEntry()
+	maxSizeEntry.SetPlaceHolder("不限制")
+	maxSizeEntry.SetText(limitText(float64(ds.MaxSize) / (1 << 20)))
+	maxSizeEntry.Validator = func(text string) error {
+		_, err := parseLimit(text)
+		return err
+	}
+
+	formItems := []*widget.FormItem{
+		widget.NewFormItem("目标格式", formatSelect),
+		widget.NewFormItem("JPEG 质量", container.NewBorder(nil, nil, nil, qualityLabel, qualitySlider)),
+		widget.NewFormItem("", widget.NewLabel("转换时按 EXIF 方向旋转并去除全部元数据（含 GPS）")),
+		widget.NewFormItem("最多图片数", maxImagesEntry),
+		widget.NewFormItem("最大总大小 (MB)", maxSizeEntry),
+		widget.NewFormItem("", widget.NewLabel("导入前检查，超出上限的一批文件整批拒绝；留空表示不限制")),
+		widget.NewFormItem("存储后端", storageForm.Content),
+	}
+	formDialog := dialog.NewForm("数据集设置 - "+ds.Name, "保存", "取消", formItems, func(confirmed bool) {
+		if !confirmed {
+			return
+		}
+		updated := *ds
+		updated.NormalizeFormat = normalizeFormats[formatSelect.SelectedIndex()].format
+		updated.NormalizeQuality = int(qualitySlider.Value)
+		maxImages, _ := parseLimit(maxImagesEntry.Text)
+		maxSize, _ := parseLimit(maxSizeEntry.Text)
+		updated.MaxImages = int(maxImages)
+		updated.MaxSize = int64(maxSize * (1 << 20))
+		if err := storageForm.Apply(&updated); err != nil {
+			dialog.ShowError(err, ui.window)
+			return
+		}
+		// 只保存设置，不写回打开窗口时读取的同步状态和封面，避免覆盖期间导入的变化
+		if err := v.repo.UpdateDatasetSettings(&updated); err != nil {
+			dialog.ShowError(err, ui.window)
+			return
+		}
+		fmt.Printf("数据集 %s 设置: 格式=%q 质量=%d 最多图片数=%d 最大总大小=%d 存储后端=%q\n",
+			ds.Name, updated.NormalizeFormat, updated.NormalizeQuality, updated.MaxImages, updated.MaxSize, updated.StorageBackend)
+		v.Reload()
+	}, ui.window)
+	formDialog.Resize(fyne.NewSize(560, 560))
+	formDialog.Show()
+}
+
+// getCover 获取数据集封面缩略图，缓存中没有时先显示默认图标，后台生成后再替换
+func (v *DatasetView) getCover(ds *models.Dataset) *canvas.Image {
+	cover := canvas.NewImageFromResource(theme.FileImageIcon())
+	cover.FillMode = canvas.ImageFillContain // 或者 ImageFillStretch，确保缩放适应容器
 	// 设置图片最小尺寸
-	thumbnail.SetMinSize(fyne.NewSize(240, 300))
+	cover.SetMinSize(fyne.NewSize(240, 300))
+	if ds.Cover == "" {
+		return cover
+	}
+
+	cache := v.ingester.Thumbnails()
+	if path := cache.Path(ds.Cover, thumbnail.SizeCard); fileExists(path) {
+		cover.Resource = nil
+		cover.File = path
+		return cover
+	}
+	go func() {
+		img, err := v.repo.FindImageBySHA256(ds.Cover)
+		if err != nil {
+			fmt.Println("获取封面图片失败:", err)
+			return
+		}
+		path, err := cache.Get(ds.Cover, img.Path, thumbnail.SizeCard)
+		if err != nil {
+			fmt.Println("生成封面缩略图失败:", err)
+			return
+		}
+		cover.Resource = nil
+		cover.File = path
+		cover.Refresh()
+	}()
+	return cover
+}
 
-	return thumbnail
+// fileExists 判断文件是否存在
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
 }
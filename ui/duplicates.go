@@ -0,0 +1,241 @@
+package ui
+
+import (
+	"fmt"
+	"path/filepath"
+	"sync"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"dataset-sync/conf"
+	"dataset-sync/ingest"
+	"dataset-sync/models"
+	"dataset-sync/phash"
+	"dataset-sync/thumbnail"
+	"dataset-sync/utils"
+)
+
+var (
+	duplicateThumbSize = fyne.NewSize(200, 200) // 查重窗口中图片的显示尺寸
+	phashAlgorithms    = []string{phash.AlgorithmPHash, phash.AlgorithmDHash, phash.AlgorithmAHash}
+)
+
+// DuplicateView 近似重复图片审查窗口：按感知哈希分组并排展示，保留一张、删除其余
+type DuplicateView struct {
+	window    fyne.Window
+	ingester  *ingest.Ingester
+	dataset   *models.Dataset
+	status    *widget.Label   // 状态提示
+	groupList *fyne.Container // 分组列表
+
+	mu         sync.Mutex
+	images     []*models.Image // 已计算感知哈希的图片
+	algorithm  string          // 当前使用的算法
+	threshold  int             // 当前使用的汉明距离阈值
+	generation int             // 分组的次数，只显示最后一次分组的结果
+}
+
+// showDuplicateWindow 打开数据集的近似重复审查窗口
+func showDuplicateWindow(ingester *ingest.Ingester, ds *models.Dataset) {
+	v := &DuplicateView{
+		window:    fyne.CurrentApp().NewWindow("近似重复图片 - " + ds.Name),
+		ingester:  ingester,
+		dataset:   ds,
+		algorithm: phash.AlgorithmPHash,
+		threshold: phash.DefaultThreshold,
+		status:    widget.NewLabel("正在计算感知哈希..."),
+		groupList: container.NewVBox(),
+	}
+	if cfg := conf.Conf.DatasetConfig; cfg != nil {
+		if cfg.PHashAlgorithm != "" {
+			v.algorithm = cfg.PHashAlgorithm
+		}
+		if cfg.PHashThreshold > 0 {
+			v.threshold = cfg.PHashThreshold
+		}
+	}
+
+	v.window.SetContent(v.createContent())
+	v.window.Resize(fyne.NewSize(1000, 700))
+	v.window.Show()
+	go v.load()
+}
+
+// createContent 创建窗口布局：顶部为算法和阈值选择，下方为分组列表
+func (v *DuplicateView) createContent() fyne.CanvasObject {
+	algorithmSelect := widget.NewSelect(phashAlgorithms, func(selected string) {
+		v.mu.Lock()
+		v.algorithm = selected
+		v.mu.Unlock()
+		v.regroup()
+	})
+	algorithmSelect.SetSelected(v.algorithm)
+
+	thresholdLabel := widget.NewLabel(fmt.Sprintf("阈值: %d", v.threshold))
+	thresholdSlider := widget.NewSlider(1, 32)
+	thresholdSlider.Step = 1
+	thresholdSlider.SetValue(float64(v.threshold))
+	thresholdSlider.OnChanged = func(value float64) {
+		thresholdLabel.SetText(fmt.Sprintf("阈值: %d", int(value)))
+	}
+	thresholdSlider.OnChangeEnded = func(value float64) {
+		v.mu.Lock()
+		v.threshold = int(value)
+		v.mu.Unlock()
+		v.regroup()
+	}
+
+	topBar := container.NewBorder(nil, nil,
+		container.NewHBox(widget.NewLabel("算法"), algorithmSelect, thresholdLabel),
+		v.status,
+		thresholdSlider,
+	)
+	return container.NewBorder(topBar, nil, nil, nil, container.NewVScroll(v.groupList))
+}
+
+// load 补算缺失的感知哈希并读取图片
+func (v *DuplicateView) load() {
+	if count, err := v.ingester.BackfillHashes(v.dataset.ID); err != nil {
+		dialog.ShowError(err, v.window)
+	} else if count > 0 {
+		fmt.Printf("数据集 %s 补算感知哈希 %d 张\n", v.dataset.Name, count)
+	}
+
+	images, err := ui.repo.ListImages(v.dataset.ID)
+	if err != nil {
+		v.status.SetText("读取图片失败")
+		dialog.ShowError(err, v.window)
+		return
+	}
+	hashed := make([]*models.Image, 0, len(images))
+	for _, img := range images {
+		if img.Hashed {
+			hashed = append(hashed, img)
+		}
+	}
+	v.mu.Lock()
+	v.images = hashed
+	v.mu.Unlock()
+	v.regroup()
+}
+
+// regroup 按当前算法和阈值重新分组，分组和读取缩略图在后台进行，完成后一次替换分组列表
+// 连续调整阈值时只显示最后一次分组的结果
+func (v *DuplicateView) regroup() {
+	v.mu.Lock()
+	if v.images == nil {
+		v.mu.Unlock()
+		return // 尚未加载完成
+	}
+	v.generation++
+	generation, all, algorithm, threshold := v.generation, v.images, v.algorithm, v.threshold
+	v.mu.Unlock()
+	v.status.SetText("正在分组...")
+
+	go func() {
+		hashes := make([]uint64, len(all))
+		for i, img := range all {
+			hashes[i] = hashOf(img, algorithm)
+		}
+		groups := phash.Group(hashes, threshold)
+
+		objects := make([]fyne.CanvasObject, 0, len(groups))
+		duplicates := 0
+		for _, group := range groups {
+			images := make([]*models.Image, len(group))
+			for i, index := range group {
+				images[i] = all[index]
+			}
+			duplicates += len(images) - 1
+			objects = append(objects, v.createGroup(images, algorithm))
+		}
+		if len(groups) == 0 {
+			objects = append(objects, widget.NewLabel("没有发现近似重复的图片"))
+		}
+
+		v.mu.Lock()
+		defer v.mu.Unlock()
+		if generation != v.generation {
+			return // 已有更新的分组
+		}
+		v.groupList.Objects = objects
+		v.groupList.Refresh()
+		v.status.SetText(fmt.Sprintf("共 %d 张图片，%d 组近似重复，可删除 %d 张", len(all), len(groups), duplicates))
+	}()
+}
+
+// hashOf 取出图片在指定算法下的哈希
+func hashOf(img *models.Image, algorithm string) uint64 {
+	return phash.Hashes{AHash: img.AHash, DHash: img.DHash, PHash: img.PHash}.Get(algorithm)
+}
+
+// createGroup 创建一组近似重复图片的并排展示
+func (v *DuplicateView) createGroup(images []*models.Image, algorithm string) fyne.CanvasObject {
+	row := container.NewHBox()
+	for _, img := range images {
+		row.Add(v.createImageCard(img, images, algorithm))
+	}
+	title := widget.NewLabelWithStyle(fmt.Sprintf("%d 张近似图片", len(images)), fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+	return container.NewVBox(title, container.NewHScroll(row), widget.NewSeparator())
+}
+
+// createImageCard 创建组内单张图片的卡片，距离按与组内第一张图片比较
+func (v *DuplicateView) createImageCard(img *models.Image, group []*models.Image, algorithm string) fyne.CanvasObject {
+	// 优先使用缩略图，生成失败时退回原图
+	previewPath := img.Path
+	if path, err := v.ingester.Thumbnails().Get(img.SHA256, img.Path, thumbnail.SizeGallery); err == nil {
+		previewPath = path
+	}
+	preview := canvas.NewImageFromFile(previewPath)
+	preview.FillMode = canvas.ImageFillContain
+	preview.SetMinSize(duplicateThumbSize)
+
+	distance := phash.Distance(hashOf(img, algorithm), hashOf(group[0], algorithm))
+	info := widget.NewLabel(fmt.Sprintf("%s\n%d×%d  %s  %s\n距离: %d",
+		filepath.Base(img.FileName), img.Width, img.Height, img.Format, utils.FormatSize(img.Size), distance))
+	info.Truncation = fyne.TextTruncateEllipsis
+
+	keepButton := widget.NewButton("保留此张", func() {
+		message := fmt.Sprintf("保留 %s，删除同组其余 %d 张图片？", img.FileName, len(group)-1)
+		dialog.ShowConfirm("确认删除", message, func(ok bool) {
+			if ok {
+				go v.keep(img, group)
+			}
+		}, v.window)
+	})
+
+	card := container.NewVBox(preview, info, keepButton)
+	return container.NewGridWrap(fyne.NewSize(duplicateThumbSize.Width, duplicateThumbSize.Height+150), card)
+}
+
+// keep 保留 kept，删除组内其余图片
+func (v *DuplicateView) keep(kept *models.Image, group []*models.Image) {
+	removed := make(map[int64]bool)
+	for _, img := range group {
+		if img.ID == kept.ID {
+			continue
+		}
+		if err := v.ingester.RemoveImage(img); err != nil {
+			dialog.ShowError(fmt.Errorf("删除 %s 失败: %w", img.FileName, err), v.window)
+			break
+		}
+		removed[img.ID] = true
+	}
+
+	v.mu.Lock()
+	remaining := make([]*models.Image, 0, len(v.images))
+	for _, img := range v.images {
+		if !removed[img.ID] {
+			remaining = append(remaining, img)
+		}
+	}
+	v.images = remaining
+	v.mu.Unlock()
+	v.regroup()
+	fmt.Printf("删除近似重复图片 %d 张\n", len(removed))
+	ui.datasetView.Reload()
+}
@@ -1,6 +1,10 @@
 package ui
 
 import (
+	"dataset-sync/conf"
+	"dataset-sync/database"
+	"dataset-sync/ingest"
+	"fmt"
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/container"
 	"fyne.io/fyne/v2/layout"
@@ -10,6 +14,10 @@ import (
 
 type MainUI struct {
 	window         fyne.Window
+	repo           database.DatasetRepository // 数据集存储
+	datasetView    *DatasetView
+	uploadView     *UploadView
+	watcher        *ingest.Watcher // 监控文件夹自动导入
 	dataset        *fyne.Container
 	upload         *fyne.Container
 	validation     *fyne.Container
@@ -22,16 +30,25 @@ type MainUI struct {
 
 var ui *MainUI // 全局变量，存储主界面实例
 
-func NewMainUI(window fyne.Window) *MainUI {
+func NewMainUI(window fyne.Window, repo database.DatasetRepository) *MainUI {
 	ui = &MainUI{
 		window: window,
+		repo:   repo,
 	}
 	ui.window.Resize(fyne.NewSize(1024, 768))
 
 	// 创建每个功能模块的容器
-	ui.dataset = CreateDatasets()
-	ui.upload = CreateUpload(window)
-	ui.settings = createSettingsView()
+	ingester := ingest.New(repo, conf.Conf.DatasetConfig)
+	ui.datasetView = NewDatasetView(repo, ingester)
+	ui.dataset = ui.datasetView.Content
+	ui.uploadView = NewUploadView(window, repo, ingester)
+	ui.upload = ui.uploadView.Content
+	ui.watcher = ingest.NewWatcher(ui.uploadView.jobs)
+	ui.watcher.OnImported = func([]ingest.Result) { ui.datasetView.Reload() }
+	if err := ui.watcher.Start(); err != nil {
+		fmt.Println("启动文件夹监控失败:", err)
+	}
+	ui.settings = createSettingsView(ingester)
 	ui.currentContent = ui.dataset
 
 	// 创建左侧导航栏
@@ -58,6 +75,7 @@ func (m *MainUI) createNavBar() fyne.CanvasObject {
 			ui.showContent(ui.dataset)
 		}),
 		widget.NewButtonWithIcon("上传", theme.UploadIcon(), func() {
+			ui.uploadView.reloadDatasets() // 数据集可能在数据集页面中新增、删除或改名
 			ui.showContent(ui.upload)
 		}),
 	)
@@ -0,0 +1,232 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	fyneDialog "fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"github.com/sqweek/dialog"
+
+	"dataset-sync/ingest"
+)
+
+const (
+	noManifest       = "（不使用清单）"
+	noColumn         = "（不使用）"
+	maxReportEntries = 50 // 匹配详情中每类最多列出的条数
+)
+
+// manifestForm 导入确认窗口中的清单文件设置：选择 CSV / JSONL 清单和列映射，并预览与待导入文件的匹配结果
+type manifestForm struct {
+	window   fyne.Window
+	items    func() []ingest.Item // 当前待导入的文件，用于预览匹配结果
+	paths    []string             // 可选的清单文件
+	manifest *ingest.Manifest     // 正在使用的清单，为空表示不使用
+
+	manifestSelect   *widget.Select
+	fileSelect       *widget.Select
+	labelSelect      *widget.Select
+	provenanceSelect *widget.Select
+	licenseSelect    *widget.Select
+	attributesCheck  *widget.Check
+	mappingForm      *fyne.Container
+	report           *widget.Label
+	detailButton     *widget.Button
+	Content          *fyne.Container
+}
+
+// newManifestForm 创建清单设置，paths 为自动识别到的清单文件，有时默认使用第一个
+func newManifestForm(window fyne.Window, paths []string, items func() []ingest.Item) *manifestForm {
+	f := &manifestForm{window: window, items: items, paths: paths}
+
+	f.manifestSelect = widget.NewSelect(nil, func(string) { f.selectManifest() })
+	browseButton := widget.NewButtonWithIcon("", theme.FolderOpenIcon(), f.browse)
+	f.fileSelect = widget.NewSelect(nil, func(string) { f.updateReport() })
+	f.labelSelect = widget.NewSelect(nil, func(string) { f.updateReport() })
+	f.provenanceSelect = widget.NewSelect(nil, func(string) { f.updateReport() })
+	f.licenseSelect = widget.NewSelect(nil, func(string) { f.updateReport() })
+	f.attributesCheck = widget.NewCheck("其余列作为自由属性保存", nil)
+	f.report = widget.NewLabel("")
+	f.report.Wrapping = fyne.TextWrapWord
+	f.detailButton = widget.NewButton("查看详情", f.showDetails)
+
+	f.mappingForm = container.NewVBox(
+		widget.NewForm(
+			widget.NewFormItem("文件名列", f.fileSelect),
+			widget.NewFormItem("标签列", f.labelSelect),
+			widget.NewFormItem("出处列", f.provenanceSelect),
+			widget.NewFormItem("许可证列", f.licenseSelect),
+		),
+		f.attributesCheck,
+		container.NewBorder(nil, nil, nil, f.detailButton, f.report),
+	)
+	f.Content = container.NewVBox(
+		widget.NewSeparator(),
+		widget.NewForm(widget.NewFormItem("清单文件", container.NewBorder(nil, nil, nil, browseButton, f.manifestSelect))),
+		f.mappingForm,
+	)
+
+	f.updateOptions()
+	if len(paths) > 0 {
+		f.manifestSelect.SetSelectedIndex(1)
+	} else {
+		f.manifestSelect.SetSelectedIndex(0)
+	}
+	return f
+}
+
+// updateOptions 更新清单文件的选项
+func (f *manifestForm) updateOptions() {
+	options := []string{noManifest}
+	for _, path := range f.paths {
+		options = append(options, filepath.Base(path))
+	}
+	f.manifestSelect.SetOptions(options)
+}
+
+// browse 选择其他清单文件
+func (f *manifestForm) browse() {
+	go func() {
+		path, err := dialog.File().Title("选择清单文件").Filter("清单文件", "csv", "jsonl", "ndjson").Load()
+		if err != nil {
+			if !errors.Is(err, dialog.ErrCancelled) {
+				fyneDialog.ShowError(err, f.window)
+			}
+			return
+		}
+		f.paths = append(f.paths, path)
+		f.updateOptions()
+		f.manifestSelect.SetSelectedIndex(len(f.paths))
+	}()
+}
+
+// selectManifest 读取选中的清单，按常见列名预填列映射
+func (f *manifestForm) selectManifest() {
+	f.manifest = nil
+	index := f.manifestSelect.SelectedIndex()
+	if index <= 0 {
+		f.mappingForm.Hide()
+		return
+	}
+	manifest, err := ingest.ReadManifest(f.paths[index-1])
+	if err != nil {
+		fyneDialog.ShowError(err, f.window)
+		f.manifestSelect.SetSelectedIndex(0)
+		return
+	}
+
+	mapping := manifest.GuessMapping()
+	optional := append([]string{noColumn}, manifest.Columns...)
+	f.fileSelect.SetOptions(manifest.Columns)
+	f.labelSelect.SetOptions(optional)
+	f.provenanceSelect.SetOptions(optional)
+	f.licenseSelect.SetOptions(optional)
+	f.fileSelect.SetSelected(mapping.File)
+	setColumn(f.labelSelect, mapping.Label)
+	setColumn(f.provenanceSelect, mapping.Provenance)
+	setColumn(f.licenseSelect, mapping.License)
+	f.attributesCheck.SetChecked(mapping.Attributes)
+	f.manifest = manifest
+	f.mappingForm.Show()
+	f.updateReport()
+}
+
+// setColumn 选中列，列名为空时选中“不使用”
+func setColumn(sel *widget.Select, column string) {
+	if column == "" {
+		column = noColumn
+	}
+	sel.SetSelected(column)
+}
+
+// column 选择框中选中的列，“不使用”时为空
+func column(sel *widget.Select) string {
+	if sel.Selected == noColumn {
+		return ""
+	}
+	return sel.Selected
+}
+
+// mapping 当前的列映射
+func (f *manifestForm) mapping() ingest.ManifestMapping {
+	return ingest.ManifestMapping{
+		File:       column(f.fileSelect),
+		Label:      column(f.labelSelect),
+		Provenance: column(f.provenanceSelect),
+		License:    column(f.licenseSelect),
+		Attributes: f.attributesCheck.Checked,
+	}
+}
+
+// Apply 按清单设置写入导入项的标签和元数据，没有使用清单时不做修改
+func (f *manifestForm) Apply(items []ingest.Item) (ingest.ManifestReport, error) {
+	if f.manifest == nil {
+		return ingest.ManifestReport{}, nil
+	}
+	return f.manifest.Apply(items, f.mapping())
+}
+
+// preview 按当前设置匹配一份待导入文件的副本
+func (f *manifestForm) preview() (ingest.ManifestReport, error) {
+	return f.Apply(f.items())
+}
+
+// updateReport 预览匹配结果
+func (f *manifestForm) updateReport() {
+	if f.manifest == nil {
+		return
+	}
+	report, err := f.preview()
+	if err != nil {
+		f.report.SetText(err.Error())
+		f.detailButton.Disable()
+		return
+	}
+	f.report.SetText(fmt.Sprintf("匹配 %d 行；%d 行没有对应的文件，%d 个文件在清单中没有对应的行",
+		report.Matched, len(report.UnmatchedRows), len(report.MissingFiles)))
+	if len(report.UnmatchedRows) > 0 || len(report.MissingFiles) > 0 {
+		f.detailButton.Enable()
+	} else {
+		f.detailButton.Disable()
+	}
+}
+
+// showDetails 列出没有对应文件的行和没有对应行的文件
+func (f *manifestForm) showDetails() {
+	report, err := f.preview()
+	if err != nil {
+		fyneDialog.ShowError(err, f.window)
+		return
+	}
+	text := widget.NewLabel(formatManifestReport(report))
+	scroll := container.NewVScroll(text)
+	scroll.SetMinSize(fyne.NewSize(480, 320))
+	fyneDialog.ShowCustom("清单匹配详情", "关闭", scroll, f.window)
+}
+
+// formatManifestReport 匹配结果的文字说明，每类最多列出 maxReportEntries 条
+func formatManifestReport(report ingest.ManifestReport) string {
+	var b strings.Builder
+	list := func(title string, entries []string) {
+		if len(entries) == 0 {
+			return
+		}
+		fmt.Fprintf(&b, "%s（%d）:\n", title, len(entries))
+		for i, entry := range entries {
+			if i == maxReportEntries {
+				fmt.Fprintf(&b, "  ... 还有 %d 条\n", len(entries)-maxReportEntries)
+				break
+			}
+			fmt.Fprintf(&b, "  %s\n", entry)
+		}
+	}
+	list("没有对应文件的行", report.UnmatchedRows)
+	list("清单中没有对应行的文件", report.MissingFiles)
+	return strings.TrimSpace(b.String())
+}
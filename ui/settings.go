@@ -2,6 +2,8 @@ package ui
 
 import (
 	"dataset-sync/conf"
+	"dataset-sync/ingest"
+	"dataset-sync/phash"
 	"dataset-sync/ui/components"
 	"dataset-sync/utils"
 	"errors"
@@ -11,10 +13,11 @@ import (
 	fyneDialog "fyne.io/fyne/v2/dialog" // 重命名以区分
 	"fyne.io/fyne/v2/widget"
 	"github.com/sqweek/dialog"
+	"strings"
 )
 
 // createSettingsView 创建设置界面
-func createSettingsView() *fyne.Container {
+func createSettingsView(ingester *ingest.Ingester) *fyne.Container {
 	// 创建设置项容器 -- 长为窗口宽度，内容宽度为 1000
 	vBoxLayout := components.NewCustomVBoxLayout(40, 20)
 
@@ -35,6 +38,38 @@ func createSettingsView() *fyne.Container {
 	}
 	autoRenameItem := components.NewSettingItem(widget.NewLabel("自动重命名"), autoRename)
 
+	// 自动重命名模板设置，输入时实时预览
+	renamePreview := widget.NewLabel("")
+	renameEntry := widget.NewEntry()
+	renameEntry.SetPlaceHolder(ingest.DefaultRenameTemplate)
+	renameEntry.SetText(conf.Conf.DatasetConfig.AutoRenameKey)
+	renameEntry.OnChanged = func(text string) {
+		renamePreview.SetText(previewRenameTemplate(text))
+	}
+	renamePreview.SetText(previewRenameTemplate(renameEntry.Text))
+	renameSaveBtn := widget.NewButton("保存", func() {
+		key := renameEntry.Text
+		if key != "" {
+			if _, err := ingest.ParseRenameTemplate(key); err != nil {
+				fyneDialog.ShowError(err, ui.window)
+				return
+			}
+		}
+		go func() {
+			if err := utils.ChangeSettings(conf.Conf.DatasetConfig, "AutoRenameKey", key); err != nil {
+				fyneDialog.ShowError(err, ui.window)
+				return
+			}
+			fmt.Println("修改重命名模板成功:", key)
+		}()
+	})
+	renameHelpBtn := widget.NewButton("变量说明", func() {
+		fyneDialog.ShowInformation("重命名模板变量", strings.Join(ingest.RenameVariables, "\n"), ui.window)
+	})
+	renameItem := components.NewSettingItem(widget.NewLabel("重命名模板"),
+		container.NewBorder(nil, nil, nil, renameSaveBtn, renameEntry))
+	renamePreviewItem := components.NewSettingItem(renamePreview, renameHelpBtn)
+
 	// 文件存放目录设置
 	saveDir := conf.Conf.DatasetConfig.SaveDir
 	saveLabel := widget.NewLabel("文件存放目录: " + saveDir)
@@ -94,9 +129,148 @@ func createSettingsView() *fyne.Container {
 	})
 	cacheSettingItem := components.NewSettingItem(cacheLabel, cacheSettingBtn)
 
+	// 近似查重算法设置
+	algorithmSelect := widget.NewSelect(phashAlgorithms, func(selected string) {
+		go func() {
+			if err := utils.ChangeSettings(conf.Conf.DatasetConfig, "PHashAlgorithm", selected); err != nil {
+				fmt.Println("修改设置失败:", err)
+				return
+			}
+			fmt.Println("修改近似查重算法成功:", selected)
+		}()
+	})
+	if conf.Conf.DatasetConfig.PHashAlgorithm != "" {
+		algorithmSelect.SetSelected(conf.Conf.DatasetConfig.PHashAlgorithm)
+	} else {
+		algorithmSelect.SetSelected(phash.AlgorithmPHash)
+	}
+	algorithmItem := components.NewSettingItem(widget.NewLabel("近似查重算法"), algorithmSelect)
+
+	// 近似查重汉明距离阈值设置
+	threshold := conf.Conf.DatasetConfig.PHashThreshold
+	if threshold <= 0 {
+		threshold = phash.DefaultThreshold
+	}
+	thresholdLabel := widget.NewLabel(fmt.Sprintf("近似查重阈值: %d", threshold))
+	thresholdSlider := widget.NewSlider(1, 32)
+	thresholdSlider.Step = 1
+	thresholdSlider.SetValue(float64(threshold))
+	thresholdSlider.OnChanged = func(value float64) {
+		thresholdLabel.SetText(fmt.Sprintf("近似查重阈值: %d", int(value)))
+	}
+	thresholdSlider.OnChangeEnded = func(value float64) {
+		go func() {
+			if err := utils.ChangeSettings(conf.Conf.DatasetConfig, "PHashThreshold", int(value)); err != nil {
+				fmt.Println("修改设置失败:", err)
+				return
+			}
+			fmt.Println("修改近似查重阈值成功:", int(value))
+		}()
+	}
+	thresholdItem := components.NewSettingItem(thresholdLabel, thresholdSlider)
+
+	// 缩略图缓存：显示占用并支持重建
+	thumbLabel := widget.NewLabel("缩略图缓存: 统计中...")
+	updateThumbLabel := func() {
+		cache := ingester.Thumbnails()
+		used, count, err := cache.Usage()
+		if err != nil {
+			thumbLabel.SetText("缩略图缓存: 读取失败")
+			return
+		}
+		thumbLabel.SetText(fmt.Sprintf("缩略图缓存: %s / %s（%d 个文件）",
+			utils.FormatSize(used), utils.FormatSize(cache.MaxBytes), count))
+	}
+	go updateThumbLabel()
+	thumbRebuildBtn := widget.NewButton("重建", func() {
+		progress := widget.NewProgressBar()
+		progressDialog := fyneDialog.NewCustomWithoutButtons("正在重建缩略图", progress, ui.window)
+		progressDialog.Show()
+		go func() {
+			generated, err := ingester.RebuildThumbnails(func(done, total int) {
+				progress.SetValue(float64(done) / float64(total))
+			})
+			progressDialog.Hide()
+			updateThumbLabel()
+			if err != nil {
+				fyneDialog.ShowError(err, ui.window)
+				return
+			}
+			fmt.Printf("重建缩略图完成，共 %d 张图片\n", generated)
+			ui.datasetView.Reload()
+		}()
+	})
+	thumbItem := components.NewSettingItem(thumbLabel, thumbRebuildBtn)
+
+	// 同时导入的文件数，修改后立即作用于当前任务队列
+	workers := ui.uploadView.jobs.Workers()
+	workersLabel := widget.NewLabel(fmt.Sprintf("同时导入数: %d", workers))
+	workersSlider := widget.NewSlider(1, ingest.MaxWorkers)
+	workersSlider.Step = 1
+	workersSlider.SetValue(float64(workers))
+	workersSlider.OnChanged = func(value float64) {
+		workersLabel.SetText(fmt.Sprintf("同时导入数: %d", int(value)))
+	}
+	workersSlider.OnChangeEnded = func(value float64) {
+		ui.uploadView.jobs.SetWorkers(int(value))
+		go func() {
+			if err := utils.ChangeSettings(conf.Conf.DatasetConfig, "UploadWorkers", int(value)); err != nil {
+				fmt.Println("修改设置失败:", err)
+				return
+			}
+			fmt.Println("修改同时导入数成功:", int(value))
+		}()
+	}
+	workersItem := components.NewSettingItem(workersLabel, workersSlider)
+
+	// 存放目录和缓存目录所在磁盘的最小剩余空间，导入前检查，0 表示不检查
+	minFreeText := func(gb int) string {
+		if gb == 0 {
+			return "最小剩余空间: 不检查"
+		}
+		return fmt.Sprintf("最小剩余空间: %d GB", gb)
+	}
+	minFree := int(conf.Conf.DatasetConfig.MinFreeSpace >> 30)
+	minFreeLabel := widget.NewLabel(minFreeText(minFree))
+	minFreeSlider := widget.NewSlider(0, 100)
+	minFreeSlider.Step = 1
+	minFreeSlider.SetValue(float64(minFree))
+	minFreeSlider.OnChanged = func(value float64) {
+		minFreeLabel.SetText(minFreeText(int(value)))
+	}
+	minFreeSlider.OnChangeEnded = func(value float64) {
+		go func() {
+			if err := utils.ChangeSettings(conf.Conf.DatasetConfig, "MinFreeSpace", int64(value)<<30); err != nil {
+				fmt.Println("修改设置失败:", err)
+				return
+			}
+			fmt.Println("修改最小剩余空间成功:", minFreeText(int(value)))
+		}()
+	}
+	minFreeItem := components.NewSettingItem(minFreeLabel, minFreeSlider)
+
 	vBoxLayout.Add(content, autoRenameItem)
+	vBoxLayout.Add(content, renameItem)
+	vBoxLayout.Add(content, renamePreviewItem)
 	vBoxLayout.Add(content, saveSettingItem)
 	vBoxLayout.Add(content, cacheSettingItem)
+	vBoxLayout.Add(content, thumbItem)
+	vBoxLayout.Add(content, algorithmItem)
+	vBoxLayout.Add(content, thresholdItem)
+	vBoxLayout.Add(content, workersItem)
+	vBoxLayout.Add(content, minFreeItem)
 
 	return container.NewBorder(nil, nil, nil, nil, container.NewScroll(content))
 }
+
+// previewRenameTemplate 用示例数据渲染重命名模板，模板为空时预览默认模板
+func previewRenameTemplate(key string) string {
+	if key == "" {
+		key = ingest.DefaultRenameTemplate
+	}
+	tmpl, err := ingest.ParseRenameTemplate(key)
+	if err != nil {
+		return "模板错误: " + err.Error()
+	}
+	return "预览: " + tmpl.Render(ingest.SampleRenameVars())
+}
@@ -0,0 +1,202 @@
+package ui
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	fyneDialog "fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"github.com/sqweek/dialog"
+
+	"dataset-sync/models"
+	"dataset-sync/storage"
+)
+
+// storageBackends 存储后端类型与显示名称
+var storageBackends = []struct {
+	backend string
+	label   string
+}{
+	{storage.BackendNone, "不同步"},
+	{storage.BackendLocal, "本地目录"},
+	{storage.BackendGitHub, "GitHub 仓库"},
+}
+
+// storageForm 数据集设置窗口中的存储后端设置，按选择的后端类型显示对应的设置项
+type storageForm struct {
+	window        fyne.Window
+	backendSelect *widget.Select
+	forms         map[string]fyne.CanvasObject // 后端类型 -> 设置项
+
+	localRoot *widget.Entry
+
+	githubRepo    *widget.Entry
+	githubBranch  *widget.Entry
+	githubPath    *widget.Entry
+	githubToken   *widget.Entry
+	githubBaseURL *widget.Entry
+
+	Content *fyne.Container
+}
+
+// newStorageForm 创建存储后端设置，按数据集当前的设置预填
+func newStorageForm(window fyne.Window, ds *models.Dataset) *storageForm {
+	f := &storageForm{window: window}
+
+	var local storage.LocalConfig
+	if ds.StorageBackend == storage.BackendLocal {
+		json.Unmarshal([]byte(ds.StorageConfig), &local)
+	}
+	f.localRoot = newEntry("同步到的文件夹，如移动硬盘或网络共享盘", local.Root)
+	browseButton := widget.NewButtonWithIcon("", theme.FolderOpenIcon(), func() {
+		go func() {
+			dir, err := dialog.Directory().Title("选择同步目录").Browse()
+			if err != nil {
+				if !errors.Is(err, dialog.ErrCancelled) {
+					fyneDialog.ShowError(err, f.window)
+				}
+				return
+			}
+			f.localRoot.SetText(dir)
+		}()
+	})
+
+	var github storage.GitHubConfig
+	if ds.StorageBackend == storage.BackendGitHub {
+		json.Unmarshal([]byte(ds.StorageConfig), &github)
+	}
+	f.githubRepo = newEntry("owner/name", github.Repo)
+	f.githubBranch = newEntry(storage.DefaultGitHubBranch, github.Branch)
+	f.githubPath = newEntry("仓库中的目录，如 datasets/cats，留空为根目录", github.Path)
+	f.githubToken = widget.NewPasswordEntry()
+	f.githubToken.SetPlaceHolder("留空时使用环境变量 GITHUB_TOKEN")
+	f.githubToken.SetText(github.Token)
+	f.githubBaseURL = newEntry(storage.DefaultGitHubAPI, github.BaseURL)
+
+	f.forms = map[string]fyne.CanvasObject{
+		storage.BackendLocal: widget.NewForm(
+			widget.NewFormItem("同步目录", container.NewBorder(nil, nil, nil, browseButton, f.localRoot)),
+		),
+		storage.BackendGitHub: widget.NewForm(
+			widget.NewFormItem("仓库", f.githubRepo),
+			widget.NewFormItem("分支", f.githubBranch),
+			widget.NewFormItem("目录", f.githubPath),
+			widget.NewFormItem("访问令牌", f.githubToken),
+			widget.NewFormItem("API 地址", f.githubBaseURL),
+		),
+	}
+
+	labels := make([]string, len(storageBackends))
+	for i, item := range storageBackends {
+		labels[i] = item.label
+	}
+	f.backendSelect = widget.NewSelect(labels, func(string) { f.showForm() })
+	f.Content = container.NewVBox(f.backendSelect)
+	for _, form := range f.forms {
+		f.Content.Add(form)
+	}
+	f.backendSelect.SetSelectedIndex(0)
+	for i, item := range storageBackends {
+		if item.backend == ds.StorageBackend {
+			f.backendSelect.SetSelectedIndex(i)
+		}
+	}
+	return f
+}
+
+// newEntry 创建带提示文字和初始内容的输入框
+func newEntry(placeHolder, text string) *widget.Entry {
+	entry := widget.NewEntry()
+	entry.SetPlaceHolder(placeHolder)
+	entry.SetText(text)
+	return entry
+}
+
+// backend 选中的后端类型
+func (f *storageForm) backend() string {
+	return storageBackends[f.backendSelect.SelectedIndex()].backend
+}
+
+// showForm 只显示选中后端的设置项
+func (f *storageForm) showForm() {
+	selected := f.backend()
+	for backend, form := range f.forms {
+		if backend == selected {
+			form.Show()
+		} else {
+			form.Hide()
+		}
+	}
+}
+
+// Apply 校验设置并写入数据集，后端设置编码为 JSON 对象；同步目标变化时由保存设置时标记为未同步
+func (f *storageForm) Apply(ds *models.Dataset) error {
+	var config any
+	switch f.backend() {
+	case storage.BackendLocal:
+		config = storage.LocalConfig{Root: f.localRoot.Text}
+	case storage.BackendGitHub:
+		config = storage.GitHubConfig{
+			Repo:    f.githubRepo.Text,
+			Branch:  f.githubBranch.Text,
+			Path:    f.githubPath.Text,
+			Token:   f.githubToken.Text,
+			BaseURL: f.githubBaseURL.Text,
+		}
+	}
+	backend, encoded := f.backend(), ""
+	if config != nil {
+		var err error
+		if encoded, err = storage.EncodeConfig(config); err != nil {
+			return err
+		}
+		if _, err := storage.New(backend, encoded); err != nil {
+			return err
+		}
+	}
+	ds.StorageBackend, ds.StorageConfig = backend, encoded
+	return nil
+}
+
+// syncDataset 把数据集推送到设置的存储后端，显示进度并可取消
+func (v *DatasetView) syncDataset(ds *models.Dataset) {
+	if ds.StorageBackend == storage.BackendNone {
+		fyneDialog.ShowInformation("提示", "请先在数据集设置中选择存储后端", ui.window)
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	progress := widget.NewProgressBar()
+	progressDialog := fyneDialog.NewCustom("正在同步 "+ds.Name, "取消", progress, ui.window)
+	progressDialog.SetOnClosed(cancel)
+	progressDialog.Show()
+	go func() {
+		defer cancel()
+		report, err := v.ingester.SyncDataset(ctx, ds, func(done, total int) {
+			progress.SetValue(float64(done) / float64(total))
+		})
+		progressDialog.Hide()
+		v.Reload()
+		if errors.Is(err, context.Canceled) {
+			return
+		}
+		if err != nil {
+			fyneDialog.ShowError(err, ui.window)
+			return
+		}
+		fmt.Printf("同步数据集 %s: 写入 %d 个，跳过 %d 个，失败 %d 个\n", ds.Name, report.Uploaded, report.Skipped, len(report.Failed))
+		message := fmt.Sprintf("写入 %d 个文件，已存在 %d 个，失败 %d 个", report.Uploaded, report.Skipped, len(report.Failed))
+		for i, failed := range report.Failed {
+			if i == maxShownErrors {
+				message += "\n..."
+				break
+			}
+			message += "\n" + failed
+		}
+		fyneDialog.ShowInformation("同步完成", message, ui.window)
+	}()
+}
@@ -1,6 +1,7 @@
 package ui
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"fyne.io/fyne/v2"
@@ -11,30 +12,124 @@ import (
 	"fyne.io/fyne/v2/widget"
 	"github.com/sqweek/dialog"
 	"image/color"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
 
+	"dataset-sync/conf"
 	"dataset-sync/database"
+	"dataset-sync/ingest"
+	"dataset-sync/models"
 	"dataset-sync/ui/components"
+	"dataset-sync/utils"
 )
 
-func CreateUpload(w fyne.Window) *fyne.Container {
+const (
+	historyLimit    = 200  // 上传历史最多显示条数
+	maxShownErrors  = 10   // 导入结果中最多列出的失败文件数
+	maxShownJobs    = 50   // 任务列表最多显示条数
+	maxRetryRecords = 1000 // 一次“重试失败”最多重新导入的记录数
+
+	jobPollInterval    = 200 * time.Millisecond // 任务列表刷新间隔
+	historyRefreshRate = time.Second            // 导入过程中上传历史的最短刷新间隔
+)
+
+// UploadView 上传界面
+type UploadView struct {
+	window      fyne.Window
+	repo        database.DatasetRepository
+	ingester    *ingest.Ingester
+	duplicates  ingest.DuplicatePolicy // 本次导入的重复图片处理方式
+	historyList *fyne.Container        // 上传历史记录列表
+	Content     *fyne.Container        // 整体布局
+
+	historyMu sync.Mutex                      // 保护上传历史列表和勾选状态
+	selected  map[int64]*models.UploadDetails // 勾选待重试的上传记录
+	retryBar  *fyne.Container                 // 重试操作栏
+
+	datasetSelect *widget.Select    // 目标数据集
+	targetID      int               // 选择的目标数据集 ID，0 表示默认数据集
+	datasets      []*models.Dataset // 可选的数据集，与 datasetSelect 的选项一一对应
+
+	jobs        *ingest.Manager   // 导入任务管理器
+	jobList     *fyne.Container   // 当前任务列表
+	jobSummary  *widget.Label     // 任务数量统计
+	pauseButton *widget.Button    // 暂停 / 继续
+	jobMu       sync.Mutex        // 保护 jobRows、shownJobs 以及 targetID、datasets，定时刷新和按钮都会更新列表
+	jobRows     map[int64]*jobRow // 正在显示的任务行
+	shownJobs   []int64           // 正在显示的任务 ID，顺序变化时重建列表
+}
+
+// jobRow 任务列表中的一行
+type jobRow struct {
+	dataset  *widget.Select // 排队中的任务可以改到其他数据集，其他任务为空
+	progress *components.CustomProgressBar
+	status   *canvas.Text
+	cancel   *widget.Button
+}
+
+// NewUploadView 创建上传界面
+func NewUploadView(w fyne.Window, repo database.DatasetRepository, ingester *ingest.Ingester) *UploadView {
+	v := &UploadView{
+		window:      w,
+		repo:        repo,
+		ingester:    ingester,
+		duplicates:  ingest.DuplicateSkip,
+		historyList: container.NewVBox(),
+		jobs:        ingest.NewManager(ingester, 0),
+		jobList:     container.NewVBox(),
+		jobRows:     make(map[int64]*jobRow),
+		selected:    make(map[int64]*models.UploadDetails),
+	}
+	if conf.Conf.DatasetConfig != nil {
+		v.targetID = conf.Conf.DatasetConfig.UploadDatasetID
+	}
+	v.retryBar = v.createRetryBar()
+	// 继续上次退出时未完成的导入任务
+	if _, err := v.jobs.Restore(v.showResults); err != nil {
+		fmt.Println("恢复导入任务失败:", err)
+	}
+	v.Content = v.createContent()
+	v.reloadDatasets()
+	// 输入框没有获得焦点时，Ctrl+V 导入剪贴板内容
+	w.Canvas().AddShortcut(&fyne.ShortcutPaste{}, func(fyne.Shortcut) {
+		if ui.currentContent == ui.upload {
+			v.pasteClipboard()
+		}
+	})
+	go v.watchJobs()
+	return v
+}
+
+// createContent 创建上传界面布局
+func (v *UploadView) createContent() *fyne.Container {
 	// 创建搜索输入框
-	searchEntry := createSearchEntry(w)
+	searchEntry := v.createSearchEntry()
 
 	// 拖放区域
-	content := createUploadPrompt(w)
-	dropArea := components.CreateDropArea(w, container.NewCenter(content))
+	content := v.createUploadPrompt()
+	dropArea := components.CreateDropArea(v.window, container.NewCenter(content), func(uris []fyne.URI) {
+		var paths []string
+		for _, uri := range uris {
+			paths = append(paths, uri.Path())
+		}
+		v.importPaths(paths)
+	})
 	dropArea.Resize(fyne.NewSize(500, 200))
 	//dropArea.Move(fyne.NewPos(0, 30)) // 往下偏移一下，避免被 label 挡住
 
-	// 上方区域  -- 搜索框和拖放区域
+	// 上方区域  -- 搜索框、导入选项和拖放区域
 	topSection := container.NewBorder(
-		searchEntry, nil, nil, nil,
+		container.NewVBox(searchEntry, v.createOptionsBar()), nil, nil, nil,
 		dropArea,
 	)
 
-	// 下方区域 -- 上传历史记录
-	historyList := createHistoryList()
-	bottomSection := container.NewVScroll(historyList)
+	// 下方区域 -- 当前任务和上传历史记录
+	v.refreshHistory()
+	bottomSection := container.NewVScroll(container.NewVBox(v.createJobBar(), v.jobList, v.historyList))
 
 	split := container.NewVSplit(topSection, bottomSection)
 	split.Offset = 0.4 // 上下比例，上面占 40%，下面占 60%
@@ -42,20 +137,387 @@ func CreateUpload(w fyne.Window) *fyne.Container {
 	return container.NewStack(split)
 }
 
-// createSearchEntry 创建搜索输入框
-func createSearchEntry(window fyne.Window) *fyne.Container {
-	searchEntry := widget.NewEntry()
-	searchEntry.SetPlaceHolder("请粘贴图片的链接")
+// duplicatePolicies 重复图片处理方式的显示名称
+var duplicatePolicies = []struct {
+	label  string
+	policy ingest.DuplicatePolicy
+}{
+	{"跳过", ingest.DuplicateSkip},
+	{"链接到已有文件", ingest.DuplicateLink},
+	{"保留副本", ingest.DuplicateCopy},
+}
+
+// createOptionsBar 创建导入选项栏：目标数据集和重复图片处理方式
+func (v *UploadView) createOptionsBar() *fyne.Container {
+	v.datasetSelect = widget.NewSelect(nil, nil)
+	v.datasetSelect.PlaceHolder = ingest.DefaultDatasetName
+	v.datasetSelect.OnChanged = func(string) {
+		v.jobMu.Lock()
+		index := v.datasetSelect.SelectedIndex()
+		if index < 0 || index >= len(v.datasets) {
+			v.jobMu.Unlock()
+			return
+		}
+		id := v.datasets[index].ID
+		changed := id != v.targetID
+		v.targetID = id
+		v.jobMu.Unlock()
+		if changed {
+			// 记住选择，下次启动时继续使用
+			if err := utils.ChangeSettings(conf.Conf.DatasetConfig, "UploadDatasetID", id); err != nil {
+				fmt.Println("保存目标数据集失败:", err)
+			}
+		}
+	}
+	newDatasetButton := widget.NewButtonWithIcon("新建", theme.ContentAddIcon(), v.showNewDataset)
+
+	var labels []string
+	for _, p := range duplicatePolicies {
+		labels = append(labels, p.label)
+	}
+	duplicateSelect := widget.NewSelect(labels, func(selected string) {
+		for _, p := range duplicatePolicies {
+			if p.label == selected {
+				v.duplicates = p.policy
+			}
+		}
+	})
+	duplicateSelect.SetSelected(duplicatePolicies[0].label)
+
+	return container.NewHBox(
+		widget.NewLabel("目标数据集:"), v.datasetSelect, newDatasetButton,
+		widget.NewLabel("重复图片:"), duplicateSelect,
+	)
+}
+
+// reloadDatasets 重新读取数据集列表，更新目标数据集选择框和排队任务的数据集选项
+// 选择的数据集已被删除时改回默认数据集
+func (v *UploadView) reloadDatasets() {
+	datasets, err := v.repo.ListDatasets()
+	if err != nil {
+		fmt.Println("获取数据集失败:", err)
+		return
+	}
+	names := make([]string, len(datasets))
+	selected := -1
+	v.jobMu.Lock()
+	for i, ds := range datasets {
+		names[i] = ds.Name
+		if ds.ID == v.targetID {
+			selected = i
+		}
+	}
+	if selected < 0 {
+		v.targetID = 0
+	}
+	v.datasets = datasets
+	v.shownJobs = nil // 下次刷新时重建任务列表，更新数据集选项
+	v.jobMu.Unlock()
+
+	v.datasetSelect.SetOptions(names)
+	if selected >= 0 {
+		v.datasetSelect.SetSelectedIndex(selected)
+	} else {
+		v.datasetSelect.ClearSelected()
+	}
+}
+
+// showNewDataset 新建数据集并设为目标数据集，同名数据集已存在时直接选择
+func (v *UploadView) showNewDataset() {
+	nameEntry := widget.NewEntry()
+	nameEntry.SetPlaceHolder("请输入要新创建的数据集名称")
+	formItems := []*widget.FormItem{
+		widget.NewFormItem("名称", nameEntry),
+	}
+	formDialog := fyneDialog.NewForm("新建数据集", "创建并选择", "取消", formItems, func(confirmed bool) {
+		if !confirmed {
+			return
+		}
+		name := strings.TrimSpace(nameEntry.Text)
+		if name == "" {
+			fyneDialog.ShowError(errors.New("名称不能为空"), v.window)
+			return
+		}
+		datasets, err := v.repo.ListDatasets()
+		if err != nil {
+			fyneDialog.ShowError(err, v.window)
+			return
+		}
+		var ds *models.Dataset
+		for _, existing := range datasets {
+			if existing.Name == name {
+				ds = existing
+			}
+		}
+		if ds == nil {
+			ds = &models.Dataset{Name: name}
+			if err := v.repo.CreateDataset(ds); err != nil {
+				fyneDialog.ShowError(err, v.window)
+				return
+			}
+			ui.datasetView.addDataset(ds)
+			fmt.Println("新数据集：", name)
+		}
+		v.jobMu.Lock()
+		v.targetID = ds.ID
+		v.jobMu.Unlock()
+		if err := utils.ChangeSettings(conf.Conf.DatasetConfig, "UploadDatasetID", ds.ID); err != nil {
+			fmt.Println("保存目标数据集失败:", err)
+		}
+		v.reloadDatasets()
+	}, v.window)
+	formDialog.Resize(fyne.NewSize(400, 200))
+	formDialog.Show()
+}
+
+// targetDataset 当前选择的目标数据集，未选择时使用默认数据集（不存在时自动创建）
+// 每次重新读取，使用数据集最新的设置
+func (v *UploadView) targetDataset() (*models.Dataset, error) {
+	v.jobMu.Lock()
+	id := v.targetID
+	v.jobMu.Unlock()
+	if id == 0 {
+		return v.ingester.EnsureDataset(ingest.DefaultDatasetName)
+	}
+	ds, err := v.repo.GetDataset(id)
+	if errors.Is(err, database.ErrNotFound) {
+		return nil, errors.New("目标数据集已被删除，请重新选择")
+	}
+	return ds, err
+}
+
+// options 当前的导入选项
+func (v *UploadView) options() ingest.Options {
+	return ingest.Options{Duplicates: v.duplicates}
+}
+
+// importPaths 导入拖放的文件和文件夹，包含文件夹、压缩包或清单文件时先预扫描，确认后再导入
+func (v *UploadView) importPaths(paths []string) {
+	go func() {
+		var (
+			items     []ingest.Item
+			scans     []*ingest.ScanResult
+			manifests []string // 拖放的清单文件和文件夹、压缩包第一层中的清单文件
+		)
+		// 解压出的临时目录在导入结束或取消后删除
+		cleanup := func() {
+			for _, scan := range scans {
+				if scan.Archive != "" {
+					os.RemoveAll(scan.Root)
+				}
+			}
+		}
+		for _, path := range paths {
+			var (
+				scan *ingest.ScanResult
+				err  error
+			)
+			if info, statErr := os.Stat(path); statErr == nil && info.IsDir() {
+				scan, err = ingest.ScanDir(path)
+			} else if ingest.IsArchive(path) {
+				scan, err = v.ingester.ExtractArchive(path)
+			} else if ingest.IsManifest(path) {
+				manifests = append(manifests, path)
+				continue
+			} else {
+				items = append(items, ingest.FileItem(path, models.SourceFile))
+				continue
+			}
+			if err != nil {
+				cleanup()
+				fyneDialog.ShowError(err, v.window)
+				return
+			}
+			scans = append(scans, scan)
+			manifests = append(manifests, ingest.FindManifests(scan.Root)...)
+		}
+
+		if len(scans) == 0 && len(manifests) == 0 {
+			v.importItems(items, nil)
+			return
+		}
+		v.confirmScan(items, scans, manifests, cleanup)
+	}()
+}
+
+// pasteClipboard 导入剪贴板内容：文本中的本地路径和图片链接，没有时读取剪贴板中的图片
+// 图片导入到当前选择的数据集，文件名按自动重命名模板生成
+func (v *UploadView) pasteClipboard() {
+	paths, urls := ingest.ParseClipboardText(v.window.Clipboard().Content())
+	if len(paths) > 0 {
+		v.importPaths(paths)
+	}
+	if len(urls) > 0 {
+		v.importURLs(urls)
+	}
+	if len(paths) > 0 || len(urls) > 0 {
+		return
+	}
+
+	go func() {
+		data, err := utils.ClipboardImage()
+		if errors.Is(err, utils.ErrNoClipboardImage) {
+			fyneDialog.ShowInformation("提示", "剪贴板中没有图片、文件路径或图片链接", v.window)
+			return
+		}
+		if err != nil {
+			fyneDialog.ShowError(err, v.window)
+			return
+		}
+		item, err := v.ingester.ClipboardItem(data)
+		if err != nil {
+			fyneDialog.ShowError(err, v.window)
+			return
+		}
+		fmt.Printf("读取剪贴板图片: %s\n", utils.FormatSize(int64(len(data))))
+		v.importItems([]ingest.Item{item}, func() { os.RemoveAll(item.TempRoot) })
+	}()
+}
+
+// confirmScan 显示文件夹、压缩包预扫描汇总（文件数量、总大小）和清单文件设置，确认后开始导入
+func (v *UploadView) confirmScan(items []ingest.Item, scans []*ingest.ScanResult, manifests []string, cleanup func()) {
+	count, skipped := len(items), 0
+	var totalSize int64
+	for _, item := range items {
+		if info, err := os.Stat(item.Path); err == nil {
+			totalSize += info.Size()
+		}
+	}
+	for _, scan := range scans {
+		count += len(scan.Files)
+		skipped += scan.Skipped
+		totalSize += scan.TotalSize
+	}
+	if count == 0 {
+		cleanup()
+		fyneDialog.ShowInformation("提示", "文件夹或压缩包中没有支持的图片文件", v.window)
+		return
+	}
+
+	summary := widget.NewLabel(fmt.Sprintf("共 %d 张图片，总大小 %s", count, utils.FormatSize(totalSize)))
+	if skipped > 0 {
+		summary.SetText(summary.Text + fmt.Sprintf("\n跳过 %d 个非图片文件", skipped))
+	}
+	labelCheck := widget.NewCheck("将第一级子文件夹名作为类别标签", nil)
+	target := widget.NewLabel("导入到: " + v.targetName())
+
+	// 全部待导入的文件，每次调用返回新的副本
+	allItems := func() []ingest.Item {
+		all := append([]ingest.Item(nil), items...)
+		for _, scan := range scans {
+			all = append(all, scan.Items(labelCheck.Checked)...)
+		}
+		return all
+	}
+	manifest := newManifestForm(v.window, manifests, allItems)
+	labelCheck.OnChanged = func(bool) { manifest.updateReport() }
+
+	confirm := fyneDialog.NewCustomConfirm("导入图片", "开始导入", "取消",
+		container.NewVBox(summary, target, labelCheck, manifest.Content), func(confirmed bool) {
+			if !confirmed {
+				cleanup()
+				return
+			}
+			all := allItems()
+			report, err := manifest.Apply(all)
+			if err != nil {
+				cleanup()
+				fyneDialog.ShowError(err, v.window)
+				return
+			}
+			if manifest.manifest != nil {
+				fmt.Printf("清单 %s: 匹配 %d 行\n%s\n", manifest.manifest.Path, report.Matched, formatManifestReport(report))
+			}
+			v.importItems(all, cleanup)
+		}, v.window)
+	confirm.Resize(fyne.NewSize(560, 0))
+	confirm.Show()
+}
+
+// importItems 将文件提交到任务队列，全部完成后刷新上传记录和数据集列表，并执行 done 清理
+func (v *UploadView) importItems(items []ingest.Item, done func()) {
+	if len(items) == 0 {
+		if done != nil {
+			done()
+		}
+		return
+	}
+	ds, err := v.targetDataset()
+	if err != nil {
+		if done != nil {
+			done()
+		}
+		fyneDialog.ShowError(err, v.window)
+		return
+	}
+	err = v.jobs.SubmitItems(ds, items, v.options(), func(results []ingest.Result) {
+		if done != nil {
+			done()
+		}
+		v.showResults(results)
+	})
+	if err != nil {
+		if done != nil {
+			done()
+		}
+		fyneDialog.ShowError(err, v.window)
+	}
+}
+
+// showResults 刷新上传记录和数据集列表，并汇总显示导入结果
+func (v *UploadView) showResults(results []ingest.Result) {
+	v.refreshHistory()
+	ui.datasetView.Reload()
+
+	succeeded, duplicates, failed, rejected, cancelled := ingest.Succeeded(results), ingest.Duplicates(results), 0, 0, 0
+	for _, r := range results {
+		switch {
+		case errors.Is(r.Err, context.Canceled):
+			cancelled++
+		case r.Err != nil:
+			failed++
+		}
+		if ingest.IsRejected(r.Err) {
+			rejected++
+		}
+	}
+	message := fmt.Sprintf("成功 %d 张，重复 %d 张，失败 %d 张", succeeded, duplicates, failed)
+	if rejected > 0 {
+		message += fmt.Sprintf("（其中 %d 张不符合校验规则）", rejected)
+	}
+	if cancelled > 0 {
+		message += fmt.Sprintf("，取消 %d 张", cancelled)
+	}
+	shown := 0
+	for _, r := range results {
+		if r.Err == nil || errors.Is(r.Err, context.Canceled) {
+			continue
+		}
+		if shown++; shown > maxShownErrors {
+			message += "\n..."
+			break
+		}
+		message += fmt.Sprintf("\n%s: %v", filepath.Base(r.Source), r.Err)
+	}
+	fyneDialog.ShowInformation("导入完成", message, v.window)
+}
+
+// createSearchEntry 创建链接输入框，多个链接每行一个
+func (v *UploadView) createSearchEntry() *fyne.Container {
+	searchEntry := widget.NewMultiLineEntry()
+	searchEntry.SetPlaceHolder("请粘贴图片的链接，多个链接每行一个")
 	searchEntry.Wrapping = fyne.TextWrapOff
+	searchEntry.SetMinRowsVisible(2)
 
 	searchButton := widget.NewButtonWithIcon("搜索", theme.SearchIcon(), func() {
-		keyword := searchEntry.Text
-		if keyword == "" {
-			fyneDialog.ShowInformation("提示", "请输入图片链接", window)
+		urls := splitURLs(searchEntry.Text)
+		if len(urls) == 0 {
+			fyneDialog.ShowInformation("提示", "请输入图片链接", v.window)
 			return
 		}
-		fmt.Printf("Captured URL: %s\n", keyword)
-		fyneDialog.ShowInformation("URL 已捕获", fmt.Sprintf("已捕获 URL: %s", keyword), window)
+		fmt.Printf("Captured URLs: %v\n", urls)
+		searchEntry.SetText("")
+		v.importURLs(urls)
 	})
 	searchButton.Importance = widget.HighImportance
 
@@ -66,33 +528,332 @@ func createSearchEntry(window fyne.Window) *fyne.Container {
 	return searchEntryContainer
 }
 
+// importURLs 将链接提交到任务队列，下载和导入进度显示在任务列表中，可单独取消
+func (v *UploadView) importURLs(urls []string) {
+	ds, err := v.targetDataset()
+	if err != nil {
+		fyneDialog.ShowError(err, v.window)
+		return
+	}
+	if err := v.jobs.SubmitURLs(ds, urls, v.options(), v.showResults); err != nil {
+		fyneDialog.ShowError(err, v.window)
+	}
+}
+
+// createJobBar 创建任务控制栏：任务统计、暂停 / 继续、全部取消、清除已完成
+func (v *UploadView) createJobBar() *fyne.Container {
+	v.jobSummary = widget.NewLabel("")
+	v.pauseButton = widget.NewButtonWithIcon("暂停", theme.MediaPauseIcon(), func() {
+		if v.jobs.Paused() {
+			v.jobs.Resume()
+		} else {
+			v.jobs.Pause()
+		}
+		v.updatePauseButton()
+	})
+	cancelButton := widget.NewButtonWithIcon("全部取消", theme.CancelIcon(), func() {
+		fyneDialog.ShowConfirm("取消导入", "确定取消全部排队中和进行中的任务吗？", func(confirmed bool) {
+			if confirmed {
+				v.jobs.CancelAll()
+			}
+		}, v.window)
+	})
+	clearButton := widget.NewButtonWithIcon("清除已完成", theme.DeleteIcon(), func() {
+		v.jobs.ClearFinished()
+		v.updateJobs()
+	})
+	return container.NewBorder(nil, nil, v.jobSummary, container.NewHBox(v.pauseButton, cancelButton, clearButton))
+}
+
+// updatePauseButton 按暂停状态切换按钮文字和图标
+func (v *UploadView) updatePauseButton() {
+	if v.jobs.Paused() {
+		v.pauseButton.SetText("继续")
+		v.pauseButton.SetIcon(theme.MediaPlayIcon())
+	} else {
+		v.pauseButton.SetText("暂停")
+		v.pauseButton.SetIcon(theme.MediaPauseIcon())
+	}
+}
+
+// watchJobs 定时刷新任务列表，有任务结束时刷新上传历史（限制频率，避免大批量导入时频繁读库）
+func (v *UploadView) watchJobs() {
+	ticker := time.NewTicker(jobPollInterval)
+	defer ticker.Stop()
+	var (
+		finished    int
+		lastHistory time.Time
+		pending     bool
+	)
+	for range ticker.C {
+		count := v.updateJobs()
+		if count != finished {
+			finished, pending = count, true
+		}
+		if pending && time.Since(lastHistory) >= historyRefreshRate {
+			v.refreshHistory()
+			lastHistory, pending = time.Now(), false
+		}
+	}
+}
+
+// updateJobs 按任务快照更新任务列表，返回已结束的任务数
+// 显示的任务集合变化时重建列表，否则只更新进度和状态
+func (v *UploadView) updateJobs() int {
+	v.jobMu.Lock()
+	defer v.jobMu.Unlock()
+	jobs := v.jobs.Jobs()
+	var queued, running, finished int
+	for _, job := range jobs {
+		switch job.State {
+		case ingest.JobQueued:
+			queued++
+		case ingest.JobRunning:
+			running++
+		default:
+			finished++
+		}
+	}
+	summary := fmt.Sprintf("进行中 %d，排队中 %d，已结束 %d，并发 %d", running, queued, finished, v.jobs.Workers())
+	if v.jobs.Paused() {
+		summary += "（已暂停）"
+	}
+	v.jobSummary.SetText(summary)
+
+	// 进行中的排在前面，其次是排队中的，最后是最近结束的
+	shown := make([]ingest.JobInfo, 0, min(len(jobs), maxShownJobs))
+	for _, state := range []ingest.JobState{ingest.JobRunning, ingest.JobQueued} {
+		for _, job := range jobs {
+			if job.State == state && len(shown) < maxShownJobs {
+				shown = append(shown, job)
+			}
+		}
+	}
+	for i := len(jobs) - 1; i >= 0 && len(shown) < maxShownJobs; i-- {
+		if state := jobs[i].State; state == ingest.JobDone || state == ingest.JobCancelled {
+			shown = append(shown, jobs[i])
+		}
+	}
+
+	if !sameJobs(v.shownJobs, shown) {
+		v.rebuildJobs(shown, len(jobs)-len(shown))
+	}
+	for _, job := range shown {
+		v.jobRows[job.ID].update(job)
+	}
+	return finished
+}
+
+// sameJobs 显示的任务及顺序是否与上次相同
+func sameJobs(ids []int64, jobs []ingest.JobInfo) bool {
+	if len(ids) != len(jobs) {
+		return false
+	}
+	for i, job := range jobs {
+		if ids[i] != job.ID {
+			return false
+		}
+	}
+	return true
+}
+
+// rebuildJobs 重建任务列表，hidden 为未显示的任务数
+func (v *UploadView) rebuildJobs(jobs []ingest.JobInfo, hidden int) {
+	v.jobList.RemoveAll()
+	v.jobRows = make(map[int64]*jobRow, len(jobs))
+	v.shownJobs = v.shownJobs[:0]
+	for i, job := range jobs {
+		row := v.newJobRow(job)
+		v.jobRows[job.ID] = row
+		v.shownJobs = append(v.shownJobs, job.ID)
+
+		var datasetCell fyne.CanvasObject = widget.NewLabel(job.Dataset)
+		if row.dataset != nil {
+			datasetCell = row.dataset
+		}
+		rowContent := container.NewGridWithColumns(7,
+			datasetCell,
+			widget.NewLabel(job.Name),
+			widget.NewLabel(job.Source),
+			widget.NewLabel(""),
+			container.NewCenter(row.progress),
+			row.status,
+			container.NewCenter(row.cancel),
+		)
+		var background *canvas.Rectangle
+		if i%2 == 0 {
+			background = canvas.NewRectangle(color.White)
+		} else {
+			background = canvas.NewRectangle(color.NRGBA{R: 240, G: 240, B: 240, A: 255})
+		}
+		background.SetMinSize(fyne.NewSize(600, 20))
+		v.jobList.Add(container.NewStack(background, rowContent))
+	}
+	if hidden > 0 {
+		v.jobList.Add(widget.NewLabel(fmt.Sprintf("还有 %d 个任务未显示", hidden)))
+	}
+}
+
+// newJobRow 创建任务行的进度条、状态和取消按钮
+func (v *UploadView) newJobRow(job ingest.JobInfo) *jobRow {
+	row := &jobRow{
+		progress: components.NewCustomProgressBar(),
+		status:   canvas.NewText("", color.Black),
+	}
+	row.status.Alignment = fyne.TextAlignCenter
+	id := job.ID
+	if job.State == ingest.JobQueued && len(v.datasets) > 0 {
+		row.dataset = v.newReassignSelect(job)
+	}
+	row.cancel = widget.NewButtonWithIcon("", theme.CancelIcon(), func() {
+		v.jobs.Cancel(id)
+	})
+	return row
+}
+
+// newReassignSelect 排队中任务的数据集选择框，开始导入前可以改到其他数据集，调用方需持有 jobMu
+func (v *UploadView) newReassignSelect(job ingest.JobInfo) *widget.Select {
+	datasets := v.datasets
+	names := make([]string, len(datasets))
+	for i, ds := range datasets {
+		names[i] = ds.Name
+	}
+	id, current := job.ID, job.Dataset
+	sel := widget.NewSelect(names, nil)
+	sel.Selected = current
+	sel.OnChanged = func(string) {
+		index := sel.SelectedIndex()
+		if index < 0 || index >= len(datasets) {
+			return
+		}
+		ds, err := v.repo.GetDataset(datasets[index].ID)
+		if err == nil {
+			err = v.jobs.Reassign(id, ds)
+		}
+		if err != nil {
+			// 恢复原来的选择，不再触发 OnChanged
+			sel.Selected = current
+			sel.Refresh()
+			fyneDialog.ShowError(err, v.window)
+			return
+		}
+		current = ds.Name
+	}
+	return sel
+}
+
+// targetName 当前目标数据集的名称
+func (v *UploadView) targetName() string {
+	if v.datasetSelect.Selected == "" {
+		return ingest.DefaultDatasetName
+	}
+	return v.datasetSelect.Selected
+}
+
+// update 按任务快照更新进度条和状态
+func (r *jobRow) update(job ingest.JobInfo) {
+	status, barColor := job.State.Label(), statusColor(models.UploadRunning)
+	switch job.State {
+	case ingest.JobRunning:
+		status = fmt.Sprintf("%s %.0f%%", job.State.Label(), job.Progress*100)
+	case ingest.JobQueued:
+		if wait := time.Until(job.NotBefore); wait > 0 {
+			status = fmt.Sprintf("%.0f 秒后重试", math.Ceil(wait.Seconds()))
+		}
+		barColor = statusColor(models.UploadQueued)
+	case ingest.JobDone, ingest.JobCancelled:
+		barColor = statusColor(models.UploadCancelled)
+		if record := job.Result.Record; record != nil {
+			status, barColor = record.UploadStatus.Label(), statusColor(record.UploadStatus)
+		}
+		r.cancel.Disable()
+	}
+	if r.dataset != nil && job.State != ingest.JobQueued {
+		r.dataset.Disable()
+	}
+	if r.progress.Value != job.Progress {
+		r.progress.SetValue(job.Progress)
+	}
+	if r.status.Text != status {
+		r.status.Text = status
+		r.status.Refresh()
+	}
+	if r.progress.BarColor != barColor {
+		r.progress.SetBarColor(barColor)
+	}
+}
+
+// statusColor 上传状态对应的颜色
+func statusColor(status models.UploadStatus) color.Color {
+	switch status {
+	case models.UploadSucceeded:
+		return color.RGBA{R: 0, G: 128, B: 0, A: 255} // 绿色
+	case models.UploadFailed:
+		return color.RGBA{R: 255, G: 0, B: 0, A: 255} // 红色
+	case models.UploadSkippedDuplicate:
+		return color.RGBA{R: 255, G: 149, B: 0, A: 255} // 橙色
+	case models.UploadCancelled:
+		return color.Gray{Y: 128} // 灰色
+	case models.UploadQueued:
+		return color.Gray{Y: 160} // 浅灰色
+	}
+	return color.RGBA{R: 0, G: 122, B: 255, A: 255} // 蓝色（进行中）
+}
+
+// splitURLs 按行拆分输入的链接，忽略空行
+func splitURLs(text string) []string {
+	var urls []string
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			urls = append(urls, line)
+		}
+	}
+	return urls
+}
+
 // createUploadPrompt 创建拖放区域提示
-func createUploadPrompt(window fyne.Window) *fyne.Container {
+func (v *UploadView) createUploadPrompt() *fyne.Container {
 	icon := canvas.NewImageFromResource(theme.UploadIcon())
 	icon.FillMode = canvas.ImageFillContain
 	icon.SetMinSize(fyne.NewSize(24, 24))
 
-	promptText := widget.NewLabel("将图片放到此处")
+	promptText := widget.NewLabel("将图片放到此处，或按 Ctrl+V 粘贴")
 	promptText.Alignment = fyne.TextAlignCenter
 
 	uploadLink := widget.NewHyperlink("上传文件", nil)
 	uploadLink.OnTapped = func() {
 		go func() {
 			// 使用system dialog库打开文件选择对话框
-			filePath, err := dialog.File().Title("选择文件上传").Load()
+			filePath, err := dialog.File().Title("选择文件上传").
+				Filter("图片文件", "jpg", "jpeg", "png", "gif", "bmp", "tif", "tiff", "webp").
+				Filter("压缩包", "zip", "tar", "gz", "tgz").Load()
 			if err != nil {
 				// 处理错误，包括用户取消
 				if errors.Is(err, dialog.ErrCancelled) {
 					return // 用户取消，不做任何处理
 				}
 				// 在主线程中显示错误
-				fyneDialog.ShowError(err, window)
+				fyneDialog.ShowError(err, v.window)
 				return
 			}
 
-			// 文件选择成功，在主线程中更新UI
+			// 文件选择成功，导入数据集
 			fmt.Printf("Captured file: %s\n", filePath)
-			fyneDialog.ShowInformation("上传成功", fmt.Sprintf("已捕获文件: %s", filePath), ui.window)
+			v.importPaths([]string{filePath})
+		}()
+	}
+
+	folderLink := widget.NewHyperlink("上传文件夹", nil)
+	folderLink.OnTapped = func() {
+		go func() {
+			dir, err := dialog.Directory().Title("选择要导入的文件夹").Browse()
+			if err != nil {
+				if !errors.Is(err, dialog.ErrCancelled) {
+					fyneDialog.ShowError(err, v.window)
+				}
+				return
+			}
+			v.importPaths([]string{dir})
 		}()
 	}
 
@@ -102,16 +863,70 @@ func createUploadPrompt(window fyne.Window) *fyne.Container {
 			promptText,
 		)),
 		container.NewCenter(widget.NewLabel("或")),
-		container.NewCenter(uploadLink),
+		container.NewCenter(container.NewHBox(uploadLink, folderLink)),
 	)
 
 	return promptContainer
 }
 
-// createHistoryList 创建上传历史记录列表
-func createHistoryList() *fyne.Container {
-	// 上传历史记录 -- 可滚动
-	historyList := container.NewVBox()
+// createRetryBar 创建重试操作栏：重试全部失败记录、重试勾选的记录
+func (v *UploadView) createRetryBar() *fyne.Container {
+	retryFailed := widget.NewButtonWithIcon("重试失败", theme.ViewRefreshIcon(), func() {
+		go func() {
+			records, err := v.repo.ListUploadHistoryByStatus(models.UploadFailed, maxRetryRecords)
+			if err != nil {
+				fyneDialog.ShowError(err, v.window)
+				return
+			}
+			if len(records) == 0 {
+				fyneDialog.ShowInformation("提示", "没有失败的上传记录", v.window)
+				return
+			}
+			fyneDialog.ShowConfirm("重试失败", fmt.Sprintf("重新导入 %d 条失败的记录吗？", len(records)), func(confirmed bool) {
+				if confirmed {
+					go v.retry(records)
+				}
+			}, v.window)
+		}()
+	})
+	retrySelected := widget.NewButtonWithIcon("重试所选", theme.ViewRefreshIcon(), func() {
+		v.historyMu.Lock()
+		records := make([]*models.UploadDetails, 0, len(v.selected))
+		for _, record := range v.selected {
+			records = append(records, record)
+		}
+		v.historyMu.Unlock()
+		if len(records) == 0 {
+			fyneDialog.ShowInformation("提示", "请先勾选失败或已取消的记录", v.window)
+			return
+		}
+		go v.retry(records)
+	})
+	return container.NewHBox(retryFailed, retrySelected)
+}
+
+// retry 重新加入导入队列，按已尝试次数延后开始
+func (v *UploadView) retry(records []*models.UploadDetails) {
+	count, err := v.jobs.Retry(records, v.options(), v.showResults)
+	v.historyMu.Lock()
+	clear(v.selected)
+	v.historyMu.Unlock()
+	v.refreshHistory()
+	if err != nil {
+		fyneDialog.ShowError(err, v.window)
+		return
+	}
+	if count == 0 {
+		fyneDialog.ShowInformation("提示", "所选记录无法重试（源文件未知或数据集已删除）", v.window)
+	}
+}
+
+// refreshHistory 重新读取并显示上传历史记录
+func (v *UploadView) refreshHistory() {
+	v.historyMu.Lock()
+	defer v.historyMu.Unlock()
+	historyList := v.historyList
+	historyList.RemoveAll()
 	// 表头
 	header := container.NewGridWithColumns(7,
 		// TODO 当前字体不支持加粗
@@ -123,10 +938,31 @@ func createHistoryList() *fyne.Container {
 		widget.NewLabelWithStyle("上传状态", fyne.TextAlignCenter, fyne.TextStyle{Bold: true}),
 		widget.NewLabelWithStyle("上传时间", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
 	)
+	// 获取上传历史记录
+	topHistory, err := v.repo.ListUploadHistory(historyLimit)
+	if err != nil {
+		fmt.Println("获取上传记录失败:", err)
+	}
+
+	// 重复统计
+	duplicates := 0
+	for _, record := range topHistory {
+		if record.Duplicate {
+			duplicates++
+		}
+	}
+	summary := widget.NewLabel(fmt.Sprintf("最近 %d 条记录，其中重复 %d 条", len(topHistory), duplicates))
+	historyList.Add(container.NewBorder(nil, nil, summary, v.retryBar))
 	historyList.Add(header) // 添加表头
 
-	// 获取上传历史记录
-	topHistory := database.GetUploadHistory()
+	// 勾选状态跨刷新保留，已不能重试的记录取消勾选
+	selected := make(map[int64]*models.UploadDetails, len(v.selected))
+	for _, record := range topHistory {
+		if v.selected[record.ID] != nil && record.Retryable() {
+			selected[record.ID] = record
+		}
+	}
+	v.selected = selected
 	// 排版输出
 	for i, record := range topHistory {
 		// 上传进度条
@@ -134,31 +970,41 @@ func createHistoryList() *fyne.Container {
 		progressBar.SetValue(getProgressValue(record.UploadStatus))
 
 		// 根据状态设置进度条颜色
-		switch record.UploadStatus {
-		case "成功":
-			progressBar.SetBarColor(color.RGBA{R: 0, G: 128, B: 0, A: 255}) // 绿色
-		case "失败":
-			progressBar.SetBarColor(color.RGBA{R: 255, G: 0, B: 0, A: 255}) // 红色
-		default:
-			progressBar.SetBarColor(color.RGBA{R: 0, G: 122, B: 255, A: 255}) // 蓝色（进行中）
-		}
+		progressBar.SetBarColor(statusColor(record.UploadStatus))
 
-		var statusColor color.Color
-		switch record.UploadStatus {
-		case "成功":
-			statusColor = color.RGBA{R: 0, G: 128, B: 0, A: 255} // 绿色
-		case "失败":
-			statusColor = color.RGBA{R: 255, G: 0, B: 0, A: 255} // 红色
-		default:
-			statusColor = color.Black // 默认黑色
+		textColor := statusColor(record.UploadStatus)
+		if !record.UploadStatus.Finished() {
+			textColor = color.Black // 排队中、导入中为黑色
 		}
 
-		statusText := canvas.NewText(record.UploadStatus, statusColor)
+		status := record.UploadStatus.Label()
+		if record.Attempts > 1 {
+			status += fmt.Sprintf("（第 %d 次）", record.Attempts)
+		}
+		statusText := canvas.NewText(status, textColor)
 		statusText.Alignment = fyne.TextAlignCenter
 
+		// 可以重试的记录在数据集名称前显示勾选框
+		var datasetCell fyne.CanvasObject = widget.NewLabelWithStyle(record.DatasetName, fyne.TextAlignLeading, fyne.TextStyle{Bold: false})
+		if record.Retryable() {
+			record := record
+			check := widget.NewCheck(record.DatasetName, nil)
+			check.Checked = v.selected[record.ID] != nil
+			check.OnChanged = func(checked bool) {
+				v.historyMu.Lock()
+				defer v.historyMu.Unlock()
+				if checked {
+					v.selected[record.ID] = record
+				} else {
+					delete(v.selected, record.ID)
+				}
+			}
+			datasetCell = check
+		}
+
 		// 创建行数据
 		rowContent := container.NewGridWithColumns(7,
-			widget.NewLabelWithStyle(record.DatasetName, fyne.TextAlignLeading, fyne.TextStyle{Bold: false}),
+			datasetCell,
 			widget.NewLabelWithStyle(record.ImageName, fyne.TextAlignLeading, fyne.TextStyle{Bold: false}),
 			widget.NewLabelWithStyle(record.ImagePath, fyne.TextAlignLeading, fyne.TextStyle{Bold: false}),
 			widget.NewLabelWithStyle(record.ImageSize, fyne.TextAlignTrailing, fyne.TextStyle{Bold: false}),
@@ -174,7 +1020,6 @@ func createHistoryList() *fyne.Container {
 		} else {
 			background = canvas.NewRectangle(color.NRGBA{R: 240, G: 240, B: 240, A: 255}) // 奇数行为浅灰色
 		}
-		background.FillColor = background.FillColor
 		background.SetMinSize(fyne.NewSize(600, 20)) // 假设总宽度 600 像素，行高 20 像素，可调整
 
 		// 使用 VBox 组合背景和行内容
@@ -184,19 +1029,22 @@ func createHistoryList() *fyne.Container {
 				rowContent,
 			),
 		)
+		// 失败时在行下方显示具体原因
+		if record.ErrorMessage != "" {
+			reason := canvas.NewText("失败原因: "+record.ErrorMessage, textColor)
+			reason.TextSize = theme.CaptionTextSize()
+			row.Add(container.NewPadded(reason))
+		}
 		historyList.Add(row)
 	}
-	return historyList
 }
 
-// getProgressValue 根据上传状态返回进度值
-func getProgressValue(status string) float64 {
+// getProgressValue 根据上传状态返回进度值，进行中的任务进度显示在任务列表中
+func getProgressValue(status models.UploadStatus) float64 {
 	switch status {
-	case "成功":
+	case models.UploadSucceeded, models.UploadSkippedDuplicate:
 		return 1.0
-	case "失败":
-		return 0.0
 	default:
-		return 0.0 // 模拟进行中状态
+		return 0.0
 	}
 }
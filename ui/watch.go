@@ -0,0 +1,206 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	fyneDialog "fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"github.com/sqweek/dialog"
+
+	"dataset-sync/ingest"
+	"dataset-sync/models"
+)
+
+// watchModes 源文件处理方式与显示名称
+var watchModes = []struct {
+	mode  string
+	label string
+}{
+	{models.WatchCopy, "复制（保留源文件）"},
+	{models.WatchMove, "移动（导入成功后删除源文件）"},
+}
+
+// watchModeLabel 处理方式的显示名称
+func watchModeLabel(mode string) string {
+	for _, m := range watchModes {
+		if m.mode == mode {
+			return m.label
+		}
+	}
+	return mode
+}
+
+// WatchFolderView 数据集的监控文件夹管理窗口
+type WatchFolderView struct {
+	window  fyne.Window
+	dataset *models.Dataset
+	list    *fyne.Container
+}
+
+// showWatchFolders 打开数据集的监控文件夹管理窗口
+func showWatchFolders(ds *models.Dataset) {
+	v := &WatchFolderView{
+		window:  fyne.CurrentApp().NewWindow("监控文件夹 - " + ds.Name),
+		dataset: ds,
+		list:    container.NewVBox(),
+	}
+	hint := widget.NewLabel("保存到监控文件夹中的图片在大小稳定后自动导入到该数据集（不包含子文件夹）")
+	hint.Wrapping = fyne.TextWrapWord
+	addButton := widget.NewButtonWithIcon("添加文件夹", theme.ContentAddIcon(), v.showAddForm)
+	addButton.Importance = widget.HighImportance
+
+	v.window.SetContent(container.NewBorder(hint, container.NewHBox(addButton), nil, nil, container.NewVScroll(v.list)))
+	v.window.Resize(fyne.NewSize(720, 420))
+	v.refresh()
+	v.window.Show()
+}
+
+// refresh 重新读取并显示该数据集的监控文件夹
+func (v *WatchFolderView) refresh() {
+	v.list.RemoveAll()
+	folders, err := ui.repo.ListWatchFolders()
+	if err != nil {
+		fyneDialog.ShowError(err, v.window)
+		return
+	}
+	count := 0
+	for _, folder := range folders {
+		if folder.DatasetID != v.dataset.ID {
+			continue
+		}
+		count++
+		v.list.Add(v.createRow(folder))
+	}
+	if count == 0 {
+		v.list.Add(widget.NewLabel("还没有监控文件夹"))
+	}
+}
+
+// createRow 创建一行监控文件夹：路径、规则、启用开关和删除按钮
+func (v *WatchFolderView) createRow(folder *models.WatchFolder) fyne.CanvasObject {
+	extensions := folder.Extensions
+	if extensions == "" {
+		extensions = "全部图片格式"
+	}
+	rules := widget.NewLabel(fmt.Sprintf("扩展名: %s    源文件: %s", extensions, watchModeLabel(folder.Mode)))
+
+	enabled := widget.NewCheck("启用", nil)
+	enabled.Checked = folder.Enabled
+	enabled.OnChanged = func(checked bool) {
+		folder.Enabled = checked
+		if err := ui.repo.UpdateWatchFolder(folder); err != nil {
+			fyneDialog.ShowError(err, v.window)
+			return
+		}
+		reloadWatcher(v.window)
+	}
+	deleteButton := widget.NewButtonWithIcon("", theme.DeleteIcon(), func() {
+		fyneDialog.ShowConfirm("删除监控文件夹", fmt.Sprintf("停止监控 %s 吗？文件夹中的文件不会被删除。", folder.Path), func(confirmed bool) {
+			if !confirmed {
+				return
+			}
+			if err := ui.repo.DeleteWatchFolder(folder.ID); err != nil {
+				fyneDialog.ShowError(err, v.window)
+				return
+			}
+			reloadWatcher(v.window)
+			v.refresh()
+		}, v.window)
+	})
+
+	return container.NewBorder(nil, widget.NewSeparator(), nil, container.NewHBox(enabled, deleteButton),
+		container.NewVBox(widget.NewLabelWithStyle(folder.Path, fyne.TextAlignLeading, fyne.TextStyle{Bold: true}), rules))
+}
+
+// showAddForm 添加监控文件夹
+func (v *WatchFolderView) showAddForm() {
+	pathEntry := widget.NewEntry()
+	pathEntry.SetPlaceHolder("要监控的文件夹")
+	browseButton := widget.NewButtonWithIcon("", theme.FolderOpenIcon(), func() {
+		go func() {
+			dir, err := dialog.Directory().Title("选择要监控的文件夹").Browse()
+			if err != nil {
+				if !errors.Is(err, dialog.ErrCancelled) {
+					fyneDialog.ShowError(err, v.window)
+				}
+				return
+			}
+			pathEntry.SetText(dir)
+		}()
+	})
+	extEntry := widget.NewEntry()
+	extEntry.SetPlaceHolder("如 jpg,png，留空接受全部图片格式")
+
+	labels := make([]string, len(watchModes))
+	for i, m := range watchModes {
+		labels[i] = m.label
+	}
+	modeSelect := widget.NewSelect(labels, nil)
+	modeSelect.SetSelectedIndex(0)
+
+	formItems := []*widget.FormItem{
+		widget.NewFormItem("文件夹", container.NewBorder(nil, nil, nil, browseButton, pathEntry)),
+		widget.NewFormItem("扩展名", extEntry),
+		widget.NewFormItem("源文件", modeSelect),
+	}
+	form := fyneDialog.NewForm("添加监控文件夹", "添加", "取消", formItems, func(confirmed bool) {
+		if !confirmed {
+			return
+		}
+		folder, err := v.newFolder(pathEntry.Text, extEntry.Text, watchModes[modeSelect.SelectedIndex()].mode)
+		if err != nil {
+			fyneDialog.ShowError(err, v.window)
+			return
+		}
+		if err := ui.repo.AddWatchFolder(folder); err != nil {
+			fyneDialog.ShowError(err, v.window)
+			return
+		}
+		fmt.Printf("数据集 %s 添加监控文件夹: %s\n", v.dataset.Name, folder.Path)
+		reloadWatcher(v.window)
+		v.refresh()
+	}, v.window)
+	form.Resize(fyne.NewSize(560, 260))
+	form.Show()
+}
+
+// newFolder 校验输入并创建监控文件夹设置
+func (v *WatchFolderView) newFolder(path, extensions, mode string) (*models.WatchFolder, error) {
+	path = strings.TrimSpace(path)
+	if path == "" {
+		return nil, errors.New("请选择要监控的文件夹")
+	}
+	info, err := os.Stat(path)
+	if err != nil || !info.IsDir() {
+		return nil, fmt.Errorf("文件夹不存在: %s", path)
+	}
+	if err := ui.watcher.CheckPath(path); err != nil {
+		return nil, err
+	}
+	exts := ingest.ParseExtensions(extensions)
+	for _, ext := range exts {
+		if !ingest.IsSupported("." + ext) {
+			return nil, fmt.Errorf("不支持的图片扩展名: %s", ext)
+		}
+	}
+	return &models.WatchFolder{
+		DatasetID:  v.dataset.ID,
+		Path:       path,
+		Extensions: strings.Join(exts, ","),
+		Mode:       mode,
+		Enabled:    true,
+	}, nil
+}
+
+// reloadWatcher 监控文件夹设置变化后重新加载
+func reloadWatcher(window fyne.Window) {
+	if err := ui.watcher.Reload(); err != nil {
+		fyneDialog.ShowError(err, window)
+	}
+}
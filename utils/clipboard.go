@@ -0,0 +1,18 @@
+package utils
+
+import "errors"
+
+// ErrNoClipboardImage 剪贴板中没有图片
+var ErrNoClipboardImage = errors.New("剪贴板中没有图片")
+
+// ClipboardImage 读取剪贴板中的图片，返回 PNG 数据
+//
+// Fyne 的剪贴板只支持文本，图片通过系统自带的命令读取：
+//   - Linux: Wayland 下使用 wl-paste（wl-clipboard），X11 下使用 xclip，需要另外安装
+//   - macOS: osascript
+//   - Windows: PowerShell
+//
+// 剪贴板中没有图片时返回 ErrNoClipboardImage
+func ClipboardImage() ([]byte, error) {
+	return clipboardImage()
+}
@@ -0,0 +1,25 @@
+package utils
+
+import (
+	"encoding/hex"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// clipboardImage 通过 osascript 以 PNG 格式读取剪贴板，输出形如 «data PNGf89504E47...»
+func clipboardImage() ([]byte, error) {
+	out, err := exec.Command("osascript", "-e", "get the clipboard as «class PNGf»").Output()
+	if err != nil {
+		return nil, ErrNoClipboardImage // 剪贴板中的内容无法转换为 PNG
+	}
+	text := strings.TrimSpace(string(out))
+	if !strings.HasPrefix(text, "«data PNGf") || !strings.HasSuffix(text, "»") {
+		return nil, ErrNoClipboardImage
+	}
+	data, err := hex.DecodeString(strings.TrimSuffix(strings.TrimPrefix(text, "«data PNGf"), "»"))
+	if err != nil {
+		return nil, fmt.Errorf("读取剪贴板图片失败: %w", err)
+	}
+	return data, nil
+}
@@ -0,0 +1,45 @@
+package utils
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// clipboardImage Wayland 下使用 wl-paste，X11 下使用 xclip，先查询剪贴板中可用的类型
+func clipboardImage() ([]byte, error) {
+	var list, read []string
+	if os.Getenv("WAYLAND_DISPLAY") != "" {
+		list = []string{"wl-paste", "--list-types"}
+		read = []string{"wl-paste", "--no-newline", "--type", "image/png"}
+	} else {
+		list = []string{"xclip", "-selection", "clipboard", "-t", "TARGETS", "-o"}
+		read = []string{"xclip", "-selection", "clipboard", "-t", "image/png", "-o"}
+	}
+	if _, err := exec.LookPath(list[0]); err != nil {
+		return nil, fmt.Errorf("读取剪贴板图片需要安装 %s: %w", list[0], err)
+	}
+
+	types, err := exec.Command(list[0], list[1:]...).Output()
+	if err != nil {
+		return nil, ErrNoClipboardImage // 剪贴板为空时命令以非零状态退出
+	}
+	if !strings.Contains(string(types), "image/png") {
+		return nil, ErrNoClipboardImage
+	}
+	data, err := exec.Command(read[0], read[1:]...).Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			err = fmt.Errorf("%w: %s", err, bytes.TrimSpace(exitErr.Stderr))
+		}
+		return nil, fmt.Errorf("读取剪贴板图片失败: %w", err)
+	}
+	if len(data) == 0 {
+		return nil, ErrNoClipboardImage
+	}
+	return data, nil
+}
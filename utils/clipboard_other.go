@@ -0,0 +1,10 @@
+//go:build !linux && !darwin && !windows
+
+package utils
+
+import "errors"
+
+// clipboardImage 其他平台不支持读取剪贴板图片
+func clipboardImage() ([]byte, error) {
+	return nil, errors.New("当前系统不支持读取剪贴板图片")
+}
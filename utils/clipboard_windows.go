@@ -0,0 +1,37 @@
+package utils
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os/exec"
+	"strings"
+	"syscall"
+)
+
+// clipboardScript 读取剪贴板中的图片并以 Base64 编码的 PNG 输出，没有图片时不输出
+const clipboardScript = `Add-Type -AssemblyName System.Windows.Forms, System.Drawing
+$img = [System.Windows.Forms.Clipboard]::GetImage()
+if ($img -ne $null) {
+	$ms = New-Object System.IO.MemoryStream
+	$img.Save($ms, [System.Drawing.Imaging.ImageFormat]::Png)
+	[Convert]::ToBase64String($ms.ToArray())
+}`
+
+// clipboardImage 通过 PowerShell 读取剪贴板，剪贴板 API 需要在 STA 线程中调用
+func clipboardImage() ([]byte, error) {
+	cmd := exec.Command("powershell", "-NoProfile", "-NonInteractive", "-STA", "-Command", clipboardScript)
+	cmd.SysProcAttr = &syscall.SysProcAttr{HideWindow: true} // 不弹出控制台窗口
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("读取剪贴板图片失败: %w", err)
+	}
+	text := strings.TrimSpace(string(out))
+	if text == "" {
+		return nil, ErrNoClipboardImage
+	}
+	data, err := base64.StdEncoding.DecodeString(text)
+	if err != nil {
+		return nil, fmt.Errorf("读取剪贴板图片失败: %w", err)
+	}
+	return data, nil
+}
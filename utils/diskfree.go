@@ -0,0 +1,30 @@
+package utils
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+)
+
+// ErrDiskFreeUnsupported 当前系统不支持查询磁盘剩余空间
+var ErrDiskFreeUnsupported = errors.New("当前系统不支持查询磁盘剩余空间")
+
+// DiskFree 返回 path 所在磁盘当前用户可用的剩余空间（字节）
+// path 还不存在时（如尚未创建的保存目录）查询最近一级已存在的上级目录
+func DiskFree(path string) (uint64, error) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return 0, err
+	}
+	for {
+		if _, err := os.Stat(path); err == nil {
+			break
+		}
+		parent := filepath.Dir(path)
+		if parent == path {
+			break
+		}
+		path = parent
+	}
+	return diskFree(path)
+}
@@ -0,0 +1,8 @@
+//go:build !linux && !darwin && !freebsd && !windows
+
+package utils
+
+// diskFree 其他系统不支持查询
+func diskFree(string) (uint64, error) {
+	return 0, ErrDiskFreeUnsupported
+}
@@ -0,0 +1,17 @@
+//go:build linux || darwin || freebsd
+
+package utils
+
+import (
+	"fmt"
+	"syscall"
+)
+
+// diskFree 通过 statfs 查询，使用非特权用户可用的块数
+func diskFree(path string) (uint64, error) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(path, &stat); err != nil {
+		return 0, fmt.Errorf("查询磁盘剩余空间失败: %w", err)
+	}
+	return uint64(stat.Bavail) * uint64(stat.Bsize), nil
+}